@@ -0,0 +1,275 @@
+package functions
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//Ways a sentence can be changed to get a variant of it
+const (
+	AUGMENT_TYPO    = "typo"
+	AUGMENT_DROP    = "drop"
+	AUGMENT_DOUBLE  = "double"
+	AUGMENT_SYNONYM = "synonym"
+	AUGMENT_SHUFFLE = "shuffle"
+	AUGMENT_ACCENTS = "accents"
+)
+
+//Words with fewer letters are never misspelled, they would become other words
+const augmentMinLetters = 3
+
+//AugmentOptions are how many variants to generate from every example, and how
+type AugmentOptions struct {
+	//Variants generated from every example, fewer when the sentence can't change that much
+	Variants int
+	//Changes to choose from at random, one for every variant
+	Kinds []string
+	//Words, or groups of words, that can replace each other, lowercase
+	Synonyms map[string][]string
+}
+
+//This function gets the default augmentation: three variants of every example with any change but synonyms,
+//that are only used once there is a synonyms file
+func DefaultAugmentOptions() AugmentOptions {
+	return AugmentOptions{Variants: 3, Kinds: []string{AUGMENT_TYPO, AUGMENT_DROP, AUGMENT_DOUBLE, AUGMENT_SHUFFLE, AUGMENT_ACCENTS}}
+}
+
+//This function reads a file of synonyms, every line a group of words that mean the same separated by commas:
+//
+//	//Comments and blank lines are ignored
+//	hamburguesa, burger
+//	refresco, soda, gaseosa
+func LoadSynonyms(file string) (map[string][]string, error) {
+	synonyms := make(map[string][]string)
+	err := scanList(file, func(number int, line string) error {
+		var group []string
+		for _, word := range strings.Split(line, ",") {
+			if word = strings.Join(strings.Fields(strings.ToLower(word)), " "); word != "" {
+				group = append(group, word)
+			}
+		}
+		//Every word of the group can be replaced by any of the others
+		for _, word := range group {
+			for _, other := range group {
+				if other != word {
+					synonyms[word] = append(synonyms[word], other)
+				}
+			}
+		}
+		return nil
+	})
+	return synonyms, err
+}
+
+//This function splits the examples of a dataset in the original ones and the generated variants
+func SplitGenerated(examples []Example) ([]Example, []Example) {
+	var originals, generated []Example
+	for _, example := range examples {
+		if example.Generated {
+			generated = append(generated, example)
+		} else {
+			originals = append(originals, example)
+		}
+	}
+	return originals, generated
+}
+
+//This function joins the generated sentences of every category after the original ones. Only the variants
+//of a sentence of the database are joined, the ones of a held out sentence, or of a sentence not known,
+//are left out so they never leak it into the training. It gets the row of the CountWords matrixes of the
+//joined database every generated row was made from, -1 for the original rows, so the validation never
+//holds them out and leaves them out of the training when the row they were made from is held out
+func JoinGenerated(db map[string][]string, generated []Example) (map[string][]string, []int) {
+	joined := make(map[string][]string)
+	for category, sentences := range db {
+		joined[category] = append([]string(nil), sentences...)
+	}
+	made_from := make(map[string][]int)
+	for _, example := range generated {
+		if found, i := Find(db[example.Category], example.Source); found && example.Source != "" {
+			joined[example.Category] = append(joined[example.Category], example.Text)
+			made_from[example.Category] = append(made_from[example.Category], i)
+		}
+	}
+	//CountWords goes through the categories in alphabetical order
+	var categories []string
+	for category := range joined {
+		categories = append(categories, category)
+	}
+	sort.Strings(categories)
+	var sources []int
+	for _, category := range categories {
+		first := len(sources)
+		for range db[category] {
+			sources = append(sources, -1)
+		}
+		for _, i := range made_from[category] {
+			sources = append(sources, first+i)
+		}
+	}
+	return joined, sources
+}
+
+//This function gets the examples of a dataset followed by the variants generated from them, tagged as Generated.
+//Variants already on the dataset are replaced by the new ones, and a variant is never the same sentence
+//as an example, or as another variant
+func Augment(examples []Example, options AugmentOptions, rng *rand.Rand) []Example {
+	originals, _ := SplitGenerated(examples)
+	output := append([]Example(nil), originals...)
+	seen := make(map[string]bool)
+	for _, example := range originals {
+		seen[lintKey(example.Text)] = true
+	}
+	for _, example := range originals {
+		//There is no sentence to change on the noanswer examples
+		if example.Text == "" {
+			continue
+		}
+		//Some changes don't apply to every sentence, give up after a few tries
+		found := 0
+		for try := 0; found < options.Variants && try < 10*options.Variants; try++ {
+			kind := options.Kinds[rng.Intn(len(options.Kinds))]
+			text, ok := augmentSentence(example.Text, kind, options.Synonyms, rng)
+			if !ok || seen[lintKey(text)] {
+				continue
+			}
+			seen[lintKey(text)] = true
+			output = append(output, Example{Text: text, Category: example.Category, Line: example.Line, Generated: true, Source: example.Text})
+			found++
+		}
+	}
+	return output
+}
+
+//This function changes a sentence in one of the ways of augmentation, it gets false when it can't be changed that way
+func augmentSentence(text string, kind string, synonyms map[string][]string, rng *rand.Rand) (string, bool) {
+	words := strings.Fields(text)
+	switch kind {
+	case AUGMENT_TYPO, AUGMENT_DROP, AUGMENT_DOUBLE:
+		var long []int
+		for i, word := range words {
+			if letters(word) >= augmentMinLetters {
+				long = append(long, i)
+			}
+		}
+		if len(long) == 0 {
+			return "", false
+		}
+		i := long[rng.Intn(len(long))]
+		word, ok := misspell(words[i], kind, rng)
+		if !ok {
+			return "", false
+		}
+		words[i] = word
+	case AUGMENT_SYNONYM:
+		//Find the words, or groups of words, that have synonyms, with no punctuation around
+		key := func(start int, end int) string {
+			return strings.ToLower(strings.TrimFunc(strings.Join(words[start:end], " "), unicode.IsPunct))
+		}
+		type match struct{ start, end int }
+		var matches []match
+		for start := range words {
+			for end := start + 1; end <= len(words); end++ {
+				if len(synonyms[key(start, end)]) > 0 {
+					matches = append(matches, match{start, end})
+				}
+			}
+		}
+		if len(matches) == 0 {
+			return "", false
+		}
+		m := matches[rng.Intn(len(matches))]
+		options := synonyms[key(m.start, m.end)]
+		words = append(append(append([]string(nil), words[:m.start]...), options[rng.Intn(len(options))]), words[m.end:]...)
+	case AUGMENT_SHUFFLE:
+		//Swap two neighbour words, the order changes but the sentence is still understood
+		if len(words) < 2 {
+			return "", false
+		}
+		i := rng.Intn(len(words) - 1)
+		words[i], words[i+1] = words[i+1], words[i]
+	case AUGMENT_ACCENTS:
+		//People often type without accents
+		result := foldAccents(text)
+		if result == text {
+			return "", false
+		}
+		return result, true
+	default:
+		return "", false
+	}
+	return strings.Join(words, " "), true
+}
+
+//This function counts the letters of a word
+func letters(word string) int {
+	n := 0
+	for _, r := range word {
+		if unicode.IsLetter(r) {
+			n++
+		}
+	}
+	return n
+}
+
+//This function misspells a word: a letter typed as a neighbour key, a missing letter or a letter typed twice
+func misspell(word string, kind string, rng *rand.Rand) (string, bool) {
+	runes := []rune(word)
+	var positions []int
+	for i, r := range runes {
+		if unicode.IsLetter(r) {
+			positions = append(positions, i)
+		}
+	}
+	i := positions[rng.Intn(len(positions))]
+	switch kind {
+	case AUGMENT_TYPO:
+		neighbours := keyboard_keys[unicode.ToLower(runes[i])]
+		if len(neighbours) == 0 {
+			return "", false
+		}
+		r := neighbours[rng.Intn(len(neighbours))]
+		if unicode.IsUpper(runes[i]) {
+			r = unicode.ToUpper(r)
+		}
+		runes[i] = r
+	case AUGMENT_DROP:
+		runes = append(runes[:i], runes[i+1:]...)
+	case AUGMENT_DOUBLE:
+		runes = append(append(append([]rune(nil), runes[:i]...), runes[i]), runes[i:]...)
+	}
+	return string(runes), true
+}
+
+//The rows of a spanish keyboard, every row starts half a key to the right of the one above
+var keyboard_rows = []string{"qwertyuiop", "asdfghjklñ", "zxcvbnm"}
+
+//The keys next to every letter, to make typos with
+var keyboard_keys = keyboard()
+
+//This function gets the keys next to every letter of the keyboard
+func keyboard() map[rune][]rune {
+	neighbours := make(map[rune][]rune)
+	rows := make([][]rune, len(keyboard_rows))
+	for i, row := range keyboard_rows {
+		rows[i] = []rune(row)
+	}
+	add := func(r rune, row int, column int) {
+		if row >= 0 && row < len(rows) && column >= 0 && column < len(rows[row]) {
+			neighbours[r] = append(neighbours[r], rows[row][column])
+		}
+	}
+	for i, row := range rows {
+		for j, r := range row {
+			add(r, i, j-1)
+			add(r, i, j+1)
+			add(r, i-1, j)
+			add(r, i-1, j+1)
+			add(r, i+1, j-1)
+			add(r, i+1, j)
+		}
+	}
+	return neighbours
+}
@@ -0,0 +1,204 @@
+package functions
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestAugmentSentence(t *testing.T) {
+	synonyms := map[string][]string{"pizza": {"pizzas"}, "buenos dias": {"hola"}}
+	tests := []struct {
+		text string
+		kind string
+		//The variant, or "" when the sentence can't be changed that way
+		want string
+	}{
+		{"buenos días", AUGMENT_ACCENTS, "buenos dias"},
+		{"hola", AUGMENT_ACCENTS, ""},
+		{"hola amigo", AUGMENT_SHUFFLE, "amigo hola"},
+		{"hola", AUGMENT_SHUFFLE, ""},
+		//The synonyms are found with no punctuation around, and can be groups of words
+		{"quiero pizza.", AUGMENT_SYNONYM, "quiero pizzas"},
+		{"Buenos dias amigo", AUGMENT_SYNONYM, "hola amigo"},
+		{"quiero sushi", AUGMENT_SYNONYM, ""},
+		//Short words are never misspelled
+		{"yo y tu", AUGMENT_TYPO, ""},
+		{"yo y tu", AUGMENT_DROP, ""},
+		{"yo y tu", AUGMENT_DOUBLE, ""},
+		{"hola", "unknown", ""},
+	}
+	for _, test := range tests {
+		got, ok := augmentSentence(test.text, test.kind, synonyms, rand.New(rand.NewSource(1)))
+		if ok != (test.want != "") || got != test.want {
+			t.Errorf("augmentSentence(%q, %s) = %q, %t, want %q", test.text, test.kind, got, ok, test.want)
+		}
+	}
+}
+
+func TestMisspell(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, kind := range []string{AUGMENT_TYPO, AUGMENT_DROP, AUGMENT_DOUBLE} {
+		for _, word := range []string{"hola", "Pizza", "niño,"} {
+			for i := 0; i < 20; i++ {
+				got, ok := misspell(word, kind, rng)
+				//Every misspelling is one edit away, and only letters are misspelled
+				if !ok || editDistance(got, word) != 1 || strings.HasSuffix(word, ",") != strings.HasSuffix(got, ",") {
+					t.Errorf("misspell(%q, %s) = %q, %t", word, kind, got, ok)
+				}
+			}
+		}
+	}
+}
+
+func TestKeyboard(t *testing.T) {
+	tests := []struct {
+		key  rune
+		want string
+	}{
+		{'a', "sqwz"},
+		{'g', "fhtyvb"},
+		{'p', "olñ"},
+		{'m', "njk"},
+	}
+	for _, test := range tests {
+		if got := string(keyboard_keys[test.key]); got != test.want {
+			t.Errorf("keys next to %q = %q, want %q", test.key, got, test.want)
+		}
+	}
+}
+
+func TestAugment(t *testing.T) {
+	examples := []Example{
+		{Text: "hola amigo", Category: "greeting", Line: 1},
+		{Text: "buenos días", Category: "greeting", Line: 2},
+		{Text: "quiero una pizza", Category: "food", Line: 3},
+		{Text: "", Category: "noanswer", Line: 4},
+		//The variants already on the dataset are replaced
+		{Text: "hola amgo", Category: "greeting", Line: 5, Generated: true, Source: "hola amigo"},
+	}
+	options := DefaultAugmentOptions()
+	got := Augment(examples, options, rand.New(rand.NewSource(1)))
+	if again := Augment(examples, options, rand.New(rand.NewSource(1))); !reflect.DeepEqual(got, again) {
+		t.Errorf("the same seed generated %v and %v", got, again)
+	}
+	originals, generated := SplitGenerated(got)
+	if !reflect.DeepEqual(originals, examples[:4]) || !reflect.DeepEqual(got[:4], examples[:4]) {
+		t.Errorf("the originals are %v, want them first and unchanged", originals)
+	}
+	seen := map[string]bool{}
+	for _, example := range examples[:4] {
+		seen[lintKey(example.Text)] = true
+	}
+	variants := map[string]int{}
+	sources := map[string]Example{}
+	for _, example := range examples[:4] {
+		sources[example.Text] = example
+	}
+	for _, example := range generated {
+		source, ok := sources[example.Source]
+		if !ok || example.Category != source.Category || example.Line != source.Line || example.Text == "" {
+			t.Errorf("variant %+v doesn't match its source", example)
+		}
+		if seen[lintKey(example.Text)] {
+			t.Errorf("variant %q is repeated", example.Text)
+		}
+		seen[lintKey(example.Text)] = true
+		variants[example.Source]++
+	}
+	for source, n := range variants {
+		if n > options.Variants {
+			t.Errorf("%d variants of %q, want at most %d", n, source, options.Variants)
+		}
+	}
+	if variants[""] != 0 || len(generated) == 0 {
+		t.Errorf("variants of every source %v", variants)
+	}
+}
+
+func TestLoadSynonyms(t *testing.T) {
+	file := writeTestFile(t, t.TempDir(), "synonyms.txt", "\uFEFF//Comments are ignored\n\nHamburguesa, burger\nrefresco,  soda ,gaseosa,\n")
+	synonyms, err := LoadSynonyms(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"hamburguesa": {"burger"},
+		"burger":      {"hamburguesa"},
+		"refresco":    {"soda", "gaseosa"},
+		"soda":        {"refresco", "gaseosa"},
+		"gaseosa":     {"refresco", "soda"},
+	}
+	if !reflect.DeepEqual(synonyms, want) {
+		t.Errorf("synonyms %v, want %v", synonyms, want)
+	}
+	if _, err := LoadSynonyms(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("LoadSynonyms of a missing file didn't fail")
+	}
+}
+
+func TestJoinGenerated(t *testing.T) {
+	db := map[string][]string{"greeting": {"hola", "buenas"}, "food": {"pizza"}}
+	tests := []struct {
+		name      string
+		generated []Example
+		want      map[string][]string
+		sources   []int
+	}{
+		{"nothing generated", nil, db, []int{-1, -1, -1}},
+		{"variants", []Example{
+			{Text: "ola", Category: "greeting", Generated: true, Source: "hola"},
+			{Text: "piza", Category: "food", Generated: true, Source: "pizza"},
+			{Text: "bueans", Category: "greeting", Generated: true, Source: "buenas"},
+		}, map[string][]string{"greeting": {"hola", "buenas", "ola", "bueans"}, "food": {"pizza", "piza"}},
+			//food goes first, then the two sentences of greeting and their variants
+			[]int{-1, 0, -1, -1, 2, 3}},
+		{"unknown sources are left out", []Example{
+			{Text: "adio", Category: "greeting", Generated: true, Source: "adios"},
+			{Text: "ola", Category: "greeting", Generated: true},
+			{Text: "pizzza", Category: "greeting", Generated: true, Source: "pizza"},
+		}, db, []int{-1, -1, -1}},
+	}
+	for _, test := range tests {
+		joined, sources := JoinGenerated(db, test.generated)
+		if !reflect.DeepEqual(joined, test.want) || !reflect.DeepEqual(sources, test.sources) {
+			t.Errorf("%s: joined %v %v, want %v %v", test.name, joined, sources, test.want, test.sources)
+		}
+	}
+	if len(db["greeting"]) != 2 {
+		t.Errorf("JoinGenerated changed the database to %v", db)
+	}
+}
+
+func TestStratifiedSplitSources(t *testing.T) {
+	//Four original rows of every category, then a variant of every original row
+	y := oneHot([]int{0, 0, 0, 0, 1, 1, 1, 1, 0, 0, 0, 0, 1, 1, 1, 1}, 2)
+	sources := []int{-1, -1, -1, -1, -1, -1, -1, -1, 0, 1, 2, 3, 4, 5, 6, 7}
+	for seed := int64(1); seed <= 5; seed++ {
+		train, validation := stratifiedSplit(y, 0.5, sources, rand.New(rand.NewSource(seed)))
+		held := map[int]bool{}
+		for _, i := range validation {
+			if sources[i] >= 0 {
+				t.Errorf("seed %d: the generated row %d is held out", seed, i)
+			}
+			held[i] = true
+		}
+		if len(validation) != 4 {
+			t.Errorf("seed %d: held out %v", seed, validation)
+		}
+		//The variants of a held out row are left out of the training, the rest are trained on
+		want := []int{}
+		for i := range sources {
+			if !held[i] && (sources[i] < 0 || !held[sources[i]]) {
+				want = append(want, i)
+			}
+		}
+		sort.Ints(train)
+		if !reflect.DeepEqual(train, want) {
+			t.Errorf("seed %d: trained on %v, want %v", seed, train, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package functions
+
+import "fmt"
+
+//bkTree is an index of words to find the closest one to another word without comparing it with
+//all of them. Every child of a node is at a different edit distance from it, so a search only
+//follows the children whose distance could hold a word close enough (Burkhard-Keller tree)
+type bkTree struct {
+	root *bkNode
+}
+
+//bkNode is a word of the tree, its position on the vocabulary, and its children by edit distance
+type bkNode struct {
+	word     string
+	index    int
+	children map[int]*bkNode
+}
+
+//Correction is a word of a sentence that is not on the vocabulary, read as the closest word that is
+type Correction struct {
+	Word     string
+	Correct  string
+	Distance int
+	//Position of the correct word on the vocabulary
+	Index int `json:"-"`
+}
+
+func (c Correction) String() string {
+	return fmt.Sprintf("%q read as %q, %d edits", c.Word, c.Correct, c.Distance)
+}
+
+//This function builds the tree of a vocabulary
+func newBKTree(words []string) *bkTree {
+	tree := &bkTree{}
+	for i, word := range words {
+		tree.add(word, i)
+	}
+	return tree
+}
+
+//This function adds a word to the tree, below the node at its distance from every node on the way
+func (t *bkTree) add(word string, index int) {
+	if t.root == nil {
+		t.root = &bkNode{word: word, index: index, children: make(map[int]*bkNode)}
+		return
+	}
+	node := t.root
+	for {
+		d := editDistance(word, node.word)
+		if d == 0 {
+			return
+		}
+		child, ok := node.children[d]
+		if !ok {
+			node.children[d] = &bkNode{word: word, index: index, children: make(map[int]*bkNode)}
+			return
+		}
+		node = child
+	}
+}
+
+//This function finds the word of the tree closest to a word, at most max edits away.
+//Between words at the same distance it takes the first one of the vocabulary
+func (t *bkTree) closest(word string, max int) (Correction, bool) {
+	best := Correction{Word: word, Distance: max + 1, Index: -1}
+	if t.root == nil {
+		return best, false
+	}
+	pending := []*bkNode{t.root}
+	for len(pending) > 0 {
+		node := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		d := editDistance(word, node.word)
+		if d < best.Distance || (d == best.Distance && node.index < best.Index) {
+			best.Correct, best.Distance, best.Index = node.word, d, node.index
+		}
+		//By the triangle inequality, only the children between d-max and d+max can be close enough
+		for k, child := range node.children {
+			if k >= d-max && k <= d+max {
+				pending = append(pending, child)
+			}
+		}
+	}
+	return best, best.Index >= 0
+}
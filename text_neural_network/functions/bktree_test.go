@@ -0,0 +1,149 @@
+package functions
+
+import (
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestBKTreeClosest(t *testing.T) {
+	words := []string{"hola", "adios", "pizza", "pizzas", "buenos", "dias", "noches", "quiero", "comer", "beber", "agua", "casa", "cosa", "caso", "niño"}
+	tree := newBKTree(words)
+	queries := []string{"hola", "ola", "holas", "piza", "pizzaz", "bunos", "dia", "noche", "qiero", "comr", "bever", "aguas", "cesa", "cas", "nino", "xyz", "", "hamburguesa"}
+	for max := 0; max <= 3; max++ {
+		for _, query := range queries {
+			//Compare with every word of the vocabulary
+			want := Correction{Word: query, Distance: max + 1, Index: -1}
+			for i, word := range words {
+				if d := editDistance(query, word); d < want.Distance {
+					want.Correct, want.Distance, want.Index = word, d, i
+				}
+			}
+			got, ok := tree.closest(query, max)
+			if ok != (want.Index >= 0) || ok && got != want {
+				t.Errorf("closest(%q, %d) = %+v, %t, want %+v", query, max, got, ok, want)
+			}
+		}
+	}
+	//With a distance that is not a metric the tree pruned acb, which is 1 edit from acab
+	if got, ok := newBKTree([]string{"abc", "bbaca", "a", "ab", "b", "acb"}).closest("acab", 1); !ok || got.Correct != "acb" {
+		t.Errorf("closest(%q, 1) = %+v, %t, want acb", "acab", got, ok)
+	}
+	if _, ok := newBKTree(nil).closest("hola", 2); ok {
+		t.Error("an empty tree found a word")
+	}
+}
+
+func TestBKTreeRandom(t *testing.T) {
+	//Short words of few letters have many swaps, the tree must find the same word as comparing with all of them
+	random := rand.New(rand.NewSource(1))
+	word := func() string {
+		letters := make([]byte, random.Intn(6))
+		for i := range letters {
+			letters[i] = "abc"[random.Intn(3)]
+		}
+		return string(letters)
+	}
+	for round := 0; round < 200; round++ {
+		var words []string
+		for i := 0; i < 12; i++ {
+			words = append(words, word())
+		}
+		tree := newBKTree(words)
+		for i := 0; i < 20; i++ {
+			query, max := word(), random.Intn(4)
+			want := Correction{Word: query, Distance: max + 1, Index: -1}
+			for j, w := range words {
+				if d := editDistance(query, w); d < want.Distance {
+					want.Correct, want.Distance, want.Index = w, d, j
+				}
+			}
+			got, ok := tree.closest(query, max)
+			if ok != (want.Index >= 0) || ok && got != want {
+				t.Fatalf("closest(%q, %d) on %q = %+v, %t, want %+v", query, max, words, got, ok, want)
+			}
+		}
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	tests := []struct {
+		word        string
+		maxDistance int
+		want        Correction
+		ok          bool
+	}{
+		{"holaa", 1, Correction{Word: "holaa", Correct: "hola", Distance: 1, Index: 0}, true},
+		{"hla", 1, Correction{Word: "hla", Correct: "hola", Distance: 1, Index: 0}, true},
+		{"piza", 2, Correction{Word: "piza", Correct: "pizza", Distance: 1, Index: 2}, true},
+		{"adioss", 2, Correction{Word: "adioss", Correct: "adios", Distance: 1, Index: 1}, true},
+		{"hoal", 1, Correction{Word: "hoal", Correct: "hola", Distance: 1, Index: 0}, true},
+		{"holaaa", 1, Correction{}, false},
+		//Two edits make a four letter word any other
+		{"hoxy", 2, Correction{}, false},
+		{"ho", 2, Correction{}, false},
+		{"xyz", 2, Correction{}, false},
+		//Typos are only corrected when the model allows them
+		{"holaa", 0, Correction{}, false},
+	}
+	for _, test := range tests {
+		model := keywordModel()
+		model.MaxDistance = test.maxDistance
+		got, ok := model.correct(test.word)
+		if ok != test.ok || got != test.want {
+			t.Errorf("correct(%q) with %d edits = %+v, %t, want %+v", test.word, test.maxDistance, got, ok, test.want)
+		}
+	}
+}
+
+func TestCorrectionString(t *testing.T) {
+	c := Correction{Word: "holaa", Correct: "hola", Distance: 1}
+	if got, want := c.String(), `"holaa" read as "hola", 1 edits`; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+func TestCategoryCorrections(t *testing.T) {
+	tests := []struct {
+		sentence    string
+		maxDistance int
+		want        string
+		corrections []Correction
+	}{
+		{"hola amigo", 1, "greeting", nil},
+		{"Holaa amigo", 1, "greeting", []Correction{{Word: "holaa", Correct: "hola", Distance: 1, Index: 0}}},
+		{"adio adioss", 1, "goodbye", []Correction{{Word: "adio", Correct: "adios", Distance: 1, Index: 1}, {Word: "adioss", Correct: "adios", Distance: 1, Index: 1}}},
+		{"Holaa amigo", 0, "noanswer", nil},
+	}
+	for _, test := range tests {
+		model := keywordModel()
+		model.MaxDistance = test.maxDistance
+		got := model.Category(test.sentence, false)
+		if got.Key != test.want || !reflect.DeepEqual(got.Corrections, test.corrections) {
+			t.Errorf("Category(%q) = %s %v, want %s %v", test.sentence, got.Key, got.Corrections, test.want, test.corrections)
+		}
+	}
+}
+
+func TestClassifyCorrections(t *testing.T) {
+	dir := t.TempDir()
+	model := keywordModel()
+	model.MaxDistance = 1
+	file := filepath.Join(dir, "model.json")
+	if err := SaveFile(file, model); err != nil {
+		t.Fatal(err)
+	}
+	bot, err := NewBot(file, writeTestFile(t, dir, "intents.json", `{"category": {"greeting": ["Hola!"], "noanswer": ["No entiendo"]}}`), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	answer, err := bot.Classify("holaa", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Correction{{Word: "holaa", Correct: "hola", Distance: 1, Index: 0}}
+	if answer[0].Key != "Hola!" || !reflect.DeepEqual(answer[0].Corrections, want) {
+		t.Errorf("answer %q %v, want %q %v", answer[0].Key, answer[0].Corrections, "Hola!", want)
+	}
+}
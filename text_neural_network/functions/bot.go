@@ -0,0 +1,66 @@
+package functions
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+)
+
+//Bot keeps the trained network and the intents responses in memory, so they are
+//read from disk only once. The model and the intents are never modified after NewBot,
+//and the random responses are chosen under a lock, so a single Bot can answer many
+//requests at the same time
+type Bot struct {
+	model   *Model
+	intents map[string][]string
+	mu      sync.Mutex
+	rng     *rand.Rand
+}
+
+//This function loads the model and the intents files and builds a Bot with them.
+//The seed chooses the responses, the same seed answers the same sentences the same way
+func NewBot(model string, intents string, seed int64) (*Bot, error) {
+	m, err := LoadFile(model)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := LoadIntens(intents)
+	if err != nil {
+		return nil, err
+	}
+	return &Bot{
+		model:   m,
+		intents: responses,
+		rng:     rand.New(rand.NewSource(seed)),
+	}, nil
+}
+
+//This function gets the score of every category for a sentence, highest first
+func (b *Bot) Predict(sentence string, details bool) Entries {
+	return b.model.Predict(sentence, details)
+}
+
+//This function gets the category of a sentence and answers with one of its responses,
+//with the typos of the sentence that were corrected to read it. It fails with ErrEmptySentence when there is nothing to classify, and with ErrNoResponse
+//when neither the category nor noanswer have responses
+func (b *Bot) Classify(sentence string, details bool) (Entries, error) {
+	if strings.TrimSpace(sentence) == "" {
+		return nil, ErrEmptySentence
+	}
+	es := Entries{b.model.Category(sentence, details)}
+	fmt.Printf("Input: %s\n Category: %v Confidence: %v\n", sentence, es[0].Key, es[0].Val)
+	for _, c := range es[0].Corrections {
+		fmt.Printf(" Typo: %v\n", c)
+	}
+	//Get the response based on the identified category
+	b.mu.Lock()
+	answer, err := response(es, b.intents, b.rng)
+	b.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	answer[0].Corrections = es[0].Corrections
+	fmt.Printf("Output: %v\n", answer[0].Key)
+	return answer, nil
+}
@@ -0,0 +1,185 @@
+package functions
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//This function writes a file of a test directory and gets its path
+func writeTestFile(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	file := filepath.Join(dir, name)
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+//This function writes a model that reads hola as greeting, adios as goodbye and pizza as food,
+//and nothing else, with the intents of greeting, goodbye and noanswer, and gets both files
+func writeKeywordBot(t *testing.T) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	//Every word turns on its own hidden neuron, and every hidden neuron its category
+	model := filepath.Join(dir, "model.json")
+	err := SaveFile(model, &Model{
+		Weights: []*mat.Dense{
+			mat.NewDense(3, 3, []float64{10, 0, 0, 0, 10, 0, 0, 0, 10}),
+			mat.NewDense(3, 3, []float64{-15, -15, 20, -15, 20, -15, 20, -15, -15}),
+		},
+		Activations: []string{SIGMOID, SIGMOID},
+		Words:       []string{"hola", "adios", "pizza"},
+		Categories:  []string{"food", "goodbye", "greeting"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	intents := writeTestFile(t, dir, "intents.json", `{"category": {"greeting": ["Hola!"], "goodbye": ["Nos vemos"], "noanswer": ["No entiendo"]}}`)
+	return model, intents
+}
+
+func TestNewBotErrors(t *testing.T) {
+	model, intents := writeKeywordBot(t)
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		model   string
+		intents string
+		err     error
+	}{
+		{"both files", model, intents, nil},
+		{"missing model", filepath.Join(dir, "missing.json"), intents, ErrModelNotFound},
+		{"corrupt model", writeTestFile(t, dir, "corrupt.json", `{"Version": 1, "Checksum": "00", "Model": {}}`), intents, ErrCorruptModel},
+		{"model that is not json", writeTestFile(t, dir, "broken_model.json", `{"Version": `), intents, ErrCorruptModel},
+		{"missing intents", model, filepath.Join(dir, "missing_intents.json"), ErrIntentsNotFound},
+		{"intents that are not json", model, writeTestFile(t, dir, "broken.json", `{"category": {`), ErrCorruptIntents},
+		{"intents without categories", model, writeTestFile(t, dir, "other.json", `{"intents": {"greeting": ["Hola!"]}}`), ErrCorruptIntents},
+	}
+	for _, test := range tests {
+		_, err := NewBot(test.model, test.intents, 1)
+		if test.err == nil && err != nil || !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+		}
+	}
+}
+
+func TestBotClassify(t *testing.T) {
+	model, _ := writeKeywordBot(t)
+	dir := t.TempDir()
+	tests := []struct {
+		name     string
+		intents  string
+		sentence string
+		want     string
+		err      error
+	}{
+		{"category with responses", `{"category": {"greeting": ["Hola!"], "noanswer": ["No entiendo"]}}`, "hola", "Hola!", nil},
+		{"accents and other words", `{"category": {"goodbye": ["Nos vemos"], "noanswer": ["No entiendo"]}}`, "Adiós amigo", "Nos vemos", nil},
+		{"not understood", `{"category": {"greeting": ["Hola!"], "noanswer": ["No entiendo"]}}`, "nada", "No entiendo", nil},
+		//food has no responses, it is answered as if it was not understood
+		{"category without responses", `{"category": {"greeting": ["Hola!"], "noanswer": ["No entiendo"]}}`, "quiero pizza", "No entiendo", nil},
+		{"no noanswer responses", `{"category": {"greeting": ["Hola!"]}}`, "pizza", "", ErrNoResponse},
+		{"empty sentence", `{"category": {"greeting": ["Hola!"]}}`, "  ", "", ErrEmptySentence},
+	}
+	for _, test := range tests {
+		bot, err := NewBot(model, writeTestFile(t, dir, "intents.json", test.intents), 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		answer, err := bot.Classify(test.sentence, false)
+		if !errors.Is(err, test.err) {
+			t.Errorf("%s: error = %v, want %v", test.name, err, test.err)
+			continue
+		}
+		if test.err == nil && (len(answer) != 1 || answer[0].Key != test.want) {
+			t.Errorf("%s: answer %v, want %q", test.name, answer, test.want)
+		}
+	}
+}
+
+func TestBotPredict(t *testing.T) {
+	model, intents := writeKeywordBot(t)
+	bot, err := NewBot(model, intents, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		sentence string
+		want     []string
+	}{
+		{"hola", []string{"greeting", "food", "goodbye"}},
+		{"adios", []string{"goodbye", "food", "greeting"}},
+		{"pizza", []string{"food", "goodbye", "greeting"}},
+	}
+	for _, test := range tests {
+		es := bot.Predict(test.sentence, false)
+		var got []string
+		for i, e := range es {
+			got = append(got, e.Key)
+			if i > 0 && e.Val > es[i-1].Val {
+				t.Errorf("Predict(%q) is not sorted: %v", test.sentence, es)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Predict(%q) = %v, want %v", test.sentence, got, test.want)
+		}
+	}
+}
+
+func TestBotConcurrent(t *testing.T) {
+	//A single bot answers many requests at the same time
+	model, intents := writeKeywordBot(t)
+	bot, err := NewBot(model, intents, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sentences := map[string]string{"hola": "Hola!", "adios": "Nos vemos", "nada": "No entiendo"}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				for sentence, want := range sentences {
+					if got, err := bot.Classify(sentence, false); err != nil || got[0].Key != want {
+						t.Errorf("Classify(%q) = %v, %v, want %q", sentence, got, err, want)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestBotSeed(t *testing.T) {
+	model, _ := writeKeywordBot(t)
+	intents := writeTestFile(t, t.TempDir(), "intents.json", `{"category": {"greeting": ["Hola!", "Buenas", "Que tal", "Hey"]}}`)
+	answers := func(seed int64) []string {
+		bot, err := NewBot(model, intents, seed)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for i := 0; i < 20; i++ {
+			answer, err := bot.Classify("hola", false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, answer[0].Key)
+		}
+		return got
+	}
+	//The same seed answers the same sentences with the same responses
+	if first, second := answers(7), answers(7); !reflect.DeepEqual(first, second) {
+		t.Errorf("the same seed answered %v and %v", first, second)
+	}
+	if first, other := answers(7), answers(8); reflect.DeepEqual(first, other) {
+		t.Errorf("another seed answered the same %v", first)
+	}
+}
@@ -0,0 +1,120 @@
+package functions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"gonum.org/v1/gonum/blas/blas64"
+	"gonum.org/v1/gonum/mat"
+)
+
+//ErrInterrupted is returned by Train when it stopped because TrainConfig.Stop was closed
+var ErrInterrupted = errors.New("training interrupted")
+
+//Checkpoint is the state of a training run, to continue it later
+type Checkpoint struct {
+	//The model so far, with its optimizer state
+	Model *Model
+	//Next epoch to train
+	Epoch int
+	Seed  int64
+	//Best weights on the validation examples, and epochs since they were found
+	Best []*mat.Dense
+	Wait int
+	//Smallest training error checked so far, the training stops when it gets bigger
+	LastMeanError float64
+}
+
+//checkpointFile is the layout of a checkpoint file
+type checkpointFile struct {
+	Epoch         int
+	Seed          int64
+	Wait          int
+	LastMeanError float64 `json:",omitempty"`
+	Model         synapse
+	Best          []blas64.General `json:",omitempty"`
+}
+
+//This function saves a checkpoint on a file. It writes a temporary file first and then
+//renames it, so an interruption never leaves a half written checkpoint
+func SaveCheckpoint(file string, cp *Checkpoint) error {
+	data := checkpointFile{Epoch: cp.Epoch, Seed: cp.Seed, Wait: cp.Wait, LastMeanError: cp.LastMeanError, Model: newSynapse(cp.Model)}
+	for _, m := range cp.Best {
+		data.Best = append(data.Best, m.RawMatrix())
+	}
+	content, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(file), filepath.Base(file)+".tmp")
+	if err != nil {
+		return err
+	}
+	//If anything fails, don't leave the temporary file behind
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(0644); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+//This function loads a checkpoint saved by SaveCheckpoint
+func LoadCheckpoint(file string) (*Checkpoint, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var data checkpointFile
+	if err := json.Unmarshal(content, &data); err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %v", file, err)
+	}
+	model, err := data.Model.load()
+	if err != nil {
+		return nil, fmt.Errorf("checkpoint %s: %v", file, err)
+	}
+	cp := &Checkpoint{Model: model, Epoch: data.Epoch, Seed: data.Seed, Wait: data.Wait, LastMeanError: data.LastMeanError}
+	for _, m := range data.Best {
+		cp.Best = append(cp.Best, mat.NewDense(m.Rows, m.Cols, m.Data))
+	}
+	return cp, nil
+}
+
+//This function checks the checkpoint was trained with the same words and categories
+func (cp *Checkpoint) compatible(words []string, categories []string) error {
+	weights := cp.Model.Weights
+	if len(weights) == 0 {
+		return fmt.Errorf("the checkpoint has no weights")
+	}
+	rows, _ := weights[0].Dims()
+	_, cols := weights[len(weights)-1].Dims()
+	if rows != len(words) || cols != len(categories) {
+		return fmt.Errorf("the checkpoint has %d words and %d categories, the database has %d and %d", rows, cols, len(words), len(categories))
+	}
+	for i := range words {
+		if cp.Model.Words[i] != words[i] {
+			return fmt.Errorf("the checkpoint words don't match the database")
+		}
+	}
+	for i := range categories {
+		if cp.Model.Categories[i] != categories[i] {
+			return fmt.Errorf("the checkpoint categories don't match the database")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package functions
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestResume(t *testing.T) {
+	_, x, y, words, categories := toyData()
+	tests := []struct {
+		name   string
+		config func(config *TrainConfig)
+		//Stop the training after the first epoch, instead of resuming from the last periodic checkpoint
+		interrupt bool
+		//Epoch the training stops at when the training error gets bigger, 0 if it never does
+		stops int
+	}{
+		{"sgd", func(config *TrainConfig) {}, false, 0},
+		{"sgd interrupted", func(config *TrainConfig) {}, true, 0},
+		//The training error checked before the checkpoint decides the stop after it
+		{"softmax stopped by the training error", func(config *TrainConfig) {
+			config.Softmax, config.Alpha = true, 10
+		}, false, 320},
+		{"adam with mini-batches and dropout", func(config *TrainConfig) {
+			config.Optimizer, config.Alpha, config.BatchSize = ADAM, 0.01, 4
+			config.Dropout, config.DropoutPercent = true, 0.2
+		}, false, 0},
+		{"momentum with validation and schedule", func(config *TrainConfig) {
+			config.Optimizer, config.Alpha, config.Validation = MOMENTUM, 0.1, 0.25
+			config.Schedule = Schedule{Name: STEP, Decay: 0.5, Step: 100}
+		}, false, 0},
+		{"rmsprop with validation interrupted", func(config *TrainConfig) {
+			config.Optimizer, config.Alpha, config.Validation, config.Softmax = RMSPROP, 0.01, 0.25, true
+		}, true, 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := toyConfig()
+			test.config(&config)
+			whole, err := Train(x, y, config, words, categories)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.stops > 0 && whole.Metrics.Epochs != test.stops {
+				t.Fatalf("the training stopped at epoch %d, want %d", whole.Metrics.Epochs, test.stops)
+			}
+
+			//Train again saving checkpoints, and continue from the last one
+			first := config
+			first.Checkpoint = filepath.Join(t.TempDir(), "checkpoint.json")
+			first.CheckpointEvery = 150
+			if test.interrupt {
+				stop := make(chan struct{})
+				close(stop)
+				first.Stop = stop
+			}
+			_, err = Train(x, y, first, words, categories)
+			if test.interrupt && !errors.Is(err, ErrInterrupted) {
+				t.Fatalf("interrupted training error = %v, want %v", err, ErrInterrupted)
+			} else if !test.interrupt && err != nil {
+				t.Fatal(err)
+			}
+			cp, err := LoadCheckpoint(first.Checkpoint)
+			if err != nil {
+				t.Fatal(err)
+			}
+			//The last periodic checkpoint is after epoch 299, and the interrupted one after the first epoch
+			want := 300
+			if test.interrupt {
+				want = 1
+			}
+			if cp.Epoch != want {
+				t.Fatalf("the checkpoint is of epoch %d, want %d", cp.Epoch, want)
+			}
+			resumed := config
+			resumed.Resume = cp
+			model, err := Train(x, y, resumed, words, categories)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range whole.Weights {
+				if !mat.Equal(model.Weights[i], whole.Weights[i]) {
+					t.Fatalf("weights %d of the resumed training are not the ones of the training that never stopped", i)
+				}
+			}
+			if model.Metrics != whole.Metrics {
+				t.Errorf("resumed metrics %+v, want %+v", model.Metrics, whole.Metrics)
+			}
+		})
+	}
+}
+
+func TestSaveLoadCheckpoint(t *testing.T) {
+	_, x, y, words, categories := toyData()
+	config := toyConfig()
+	config.Epochs, config.Optimizer, config.Alpha = 10, ADAM, 0.01
+	model, err := Train(x, y, config, words, categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := &Checkpoint{Model: model, Epoch: 7, Seed: 42, Best: copyAll(model.Weights), Wait: 3, LastMeanError: 0.25}
+	file := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := SaveCheckpoint(file, cp); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadCheckpoint(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Epoch != cp.Epoch || got.Seed != cp.Seed || got.Wait != cp.Wait || got.LastMeanError != cp.LastMeanError || len(got.Best) != len(cp.Best) {
+		t.Fatalf("loaded epoch %d, seed %d, wait %d, error %v and %d best matrixes, want %d, %d, %d, %v and %d",
+			got.Epoch, got.Seed, got.Wait, got.LastMeanError, len(got.Best), cp.Epoch, cp.Seed, cp.Wait, cp.LastMeanError, len(cp.Best))
+	}
+	for i := range model.Weights {
+		if !mat.Equal(got.Model.Weights[i], model.Weights[i]) || !mat.Equal(got.Best[i], cp.Best[i]) {
+			t.Errorf("weights %d changed on the checkpoint file", i)
+		}
+	}
+	if got.Model.Optimizer.Steps != model.Optimizer.Steps || len(got.Model.Optimizer.First) != len(model.Optimizer.First) {
+		t.Errorf("loaded optimizer %+v, want %+v", got.Model.Optimizer, model.Optimizer)
+	}
+}
+
+func TestCheckpointCompatible(t *testing.T) {
+	_, x, y, words, categories := toyData()
+	config := toyConfig()
+	config.Epochs = 10
+	model, err := Train(x, y, config, words, categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cp := &Checkpoint{Model: model, Epoch: 5}
+	other := append([]string(nil), words...)
+	other[0] = "otra"
+	tests := []struct {
+		name       string
+		words      []string
+		categories []string
+		err        bool
+	}{
+		{"same database", words, categories, false},
+		{"one more word", append(append([]string(nil), words...), "nueva"), categories, true},
+		{"another word", other, categories, true},
+		{"categories in another order", words, []string{categories[1], categories[0], categories[2]}, true},
+		{"one category less", words, categories[:2], true},
+	}
+	for _, test := range tests {
+		if err := cp.compatible(test.words, test.categories); (err != nil) != test.err {
+			t.Errorf("%s: error = %v, want error %t", test.name, err, test.err)
+		}
+	}
+	if _, err := LoadCheckpoint(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadCheckpoint of a missing file didn't fail")
+	}
+}
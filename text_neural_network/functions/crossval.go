@@ -0,0 +1,138 @@
+package functions
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"sort"
+	"text/tabwriter"
+)
+
+//CrossValidation is the result of training and evaluating a model on every fold of a database
+type CrossValidation struct {
+	Folds            []Report
+	MeanAccuracy     float64
+	VarianceAccuracy float64
+	Categories       []CategoryScore
+}
+
+//CategoryScore is the F1 of a category over the folds where it was tested
+type CategoryScore struct {
+	Category   string
+	MeanF1     float64
+	VarianceF1 float64
+	Folds      int
+}
+
+//This function splits the database in k folds keeping the proportion of every category,
+//trains a model with k-1 folds and evaluates it on the one left, once for every fold.
+//The generated sentences are never tested, they are trained with on the folds where the sentence they were made from is
+func CrossValidate(db map[string][]string, generated []Example, config TrainConfig, k int) (CrossValidation, error) {
+	var cv CrossValidation
+	if k < 2 {
+		return cv, fmt.Errorf("cross validation needs at least 2 folds, got %d", k)
+	}
+	folds := stratifiedFolds(db, k, rand.New(rand.NewSource(config.Seed)))
+	_, categories := Vocabulary(db, config.preprocessing())
+	for i := range folds {
+		fmt.Printf("\nFold %d/%d\n", i+1, k)
+		//Every fold but the one being tested is training data
+		train_db := make(map[string][]string)
+		for j, fold := range folds {
+			if j == i {
+				continue
+			}
+			for category, sentences := range fold {
+				train_db[category] = append(train_db[category], sentences...)
+			}
+		}
+		//The vocabulary only comes from the training folds, like it would with new sentences
+		train_db, config.Sources = JoinGenerated(train_db, generated)
+		words, _ := Vocabulary(train_db, config.preprocessing())
+		x, y := CountWords(train_db, words, categories, config.preprocessing())
+		model, err := Train(x, y, config, words, categories)
+		if err != nil {
+			return cv, err
+		}
+		cv.Folds = append(cv.Folds, Evaluate(model, folds[i]))
+	}
+
+	//Get the mean and variance of the accuracy, and of the F1 of every category
+	var accuracies []float64
+	f1 := make(map[string][]float64)
+	for _, report := range cv.Folds {
+		accuracies = append(accuracies, report.Accuracy)
+		for _, c := range report.Categories {
+			//A category that was not tested on a fold has no F1 there
+			if c.Support > 0 {
+				f1[c.Category] = append(f1[c.Category], c.F1)
+			}
+		}
+	}
+	cv.MeanAccuracy, cv.VarianceAccuracy = meanVariance(accuracies)
+	for _, category := range categories {
+		score := CategoryScore{Category: category, Folds: len(f1[category])}
+		score.MeanF1, score.VarianceF1 = meanVariance(f1[category])
+		cv.Categories = append(cv.Categories, score)
+	}
+	return cv, nil
+}
+
+//This function deals the sentences of every category, shuffled, one by one into k folds
+func stratifiedFolds(db map[string][]string, k int, rng *rand.Rand) []map[string][]string {
+	folds := make([]map[string][]string, k)
+	for i := range folds {
+		folds[i] = make(map[string][]string)
+	}
+	var keys []string
+	for category := range db {
+		keys = append(keys, category)
+	}
+	sort.Strings(keys)
+	//Keep dealing where the last category stopped, so small categories don't all land on the first fold
+	next := 0
+	for _, category := range keys {
+		sentences := append([]string(nil), db[category]...)
+		rng.Shuffle(len(sentences), func(i, j int) { sentences[i], sentences[j] = sentences[j], sentences[i] })
+		for _, sentence := range sentences {
+			folds[next][category] = append(folds[next][category], sentence)
+			next = (next + 1) % k
+		}
+	}
+	return folds
+}
+
+//This function gets the mean and the sample variance of a list of values
+func meanVariance(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	var mean, variance float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	for _, v := range values {
+		variance += (v - mean) * (v - mean)
+	}
+	return mean, variance / float64(len(values)-1)
+}
+
+//This function writes the accuracy of every fold, and the mean and variance of the accuracy and F1 scores
+func (cv CrossValidation) Print(out io.Writer) {
+	fmt.Fprintln(out)
+	for i, report := range cv.Folds {
+		fmt.Fprintf(out, "Fold %d accuracy: %.4f (%d/%d)\n", i+1, report.Accuracy, report.Correct, report.Total)
+	}
+	fmt.Fprintf(out, "Accuracy mean: %.4f  variance: %.6f  std: %.4f\n\n", cv.MeanAccuracy, cv.VarianceAccuracy, math.Sqrt(cv.VarianceAccuracy))
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "category\tmean f1\tvariance\tfolds tested")
+	for _, c := range cv.Categories {
+		fmt.Fprintf(w, "%s\t%.4f\t%.6f\t%d\n", c.Category, c.MeanF1, c.VarianceF1, c.Folds)
+	}
+	w.Flush()
+}
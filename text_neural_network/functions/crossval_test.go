@@ -0,0 +1,112 @@
+package functions
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestStratifiedFolds(t *testing.T) {
+	db := map[string][]string{"a": {"a1", "a2", "a3", "a4", "a5"}, "b": {"b1", "b2", "b3"}, "c": {"c1"}}
+	for _, k := range []int{2, 3, 4, 9, 12} {
+		folds := stratifiedFolds(db, k, rand.New(rand.NewSource(1)))
+		if len(folds) != k {
+			t.Errorf("k %d: %d folds", k, len(folds))
+			continue
+		}
+		var all []string
+		min, max := math.MaxInt32, 0
+		for _, fold := range folds {
+			size := 0
+			for category, sentences := range fold {
+				for _, sentence := range sentences {
+					if !strings.HasPrefix(sentence, category) {
+						t.Errorf("k %d: %s is on category %s", k, sentence, category)
+					}
+				}
+				size += len(sentences)
+				all = append(all, sentences...)
+			}
+			if size < min {
+				min = size
+			}
+			if size > max {
+				max = size
+			}
+			//Every category is dealt evenly, so it has the same sentences on every fold, or one more
+			for category, sentences := range db {
+				if n := len(fold[category]); n < len(sentences)/k || n > len(sentences)/k+1 {
+					t.Errorf("k %d: a fold has %d sentences of %s", k, n, category)
+				}
+			}
+		}
+		//The folds are as big as each other, but for one sentence
+		if max-min > 1 {
+			t.Errorf("k %d: the folds have from %d to %d sentences", k, min, max)
+		}
+		//Every sentence is on exactly one fold
+		sort.Strings(all)
+		want := []string{"a1", "a2", "a3", "a4", "a5", "b1", "b2", "b3", "c1"}
+		if strings.Join(all, " ") != strings.Join(want, " ") {
+			t.Errorf("k %d: the folds have %v, want %v", k, all, want)
+		}
+	}
+}
+
+func TestMeanVariance(t *testing.T) {
+	tests := []struct {
+		values         []float64
+		mean, variance float64
+	}{
+		{nil, 0, 0},
+		{[]float64{0.7}, 0.7, 0},
+		{[]float64{1, 1, 1}, 1, 0},
+		//Sample variance, divided by one less than the number of values
+		{[]float64{1, 2, 3, 4}, 2.5, 5.0 / 3},
+		{[]float64{0.5, 1}, 0.75, 0.125},
+	}
+	for _, test := range tests {
+		mean, variance := meanVariance(test.values)
+		if !near(mean, test.mean) || !near(variance, test.variance) {
+			t.Errorf("meanVariance(%v) = %v, %v, want %v, %v", test.values, mean, variance, test.mean, test.variance)
+		}
+	}
+}
+
+func TestCrossValidate(t *testing.T) {
+	db, _, _, _, _ := toyData()
+	tests := []struct {
+		k   int
+		err bool
+	}{
+		{1, true},
+		{2, false},
+		{4, false},
+	}
+	for _, test := range tests {
+		cv, err := CrossValidate(db, nil, toyConfig(), test.k)
+		if (err != nil) != test.err {
+			t.Errorf("k %d: error = %v, want error %t", test.k, err, test.err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if len(cv.Folds) != test.k {
+			t.Errorf("k %d: %d folds evaluated", test.k, len(cv.Folds))
+		}
+		//Every sentence is tested once
+		total := 0
+		for _, report := range cv.Folds {
+			total += report.Total
+		}
+		if total != len(toyExamples) {
+			t.Errorf("k %d: tested %d sentences, want %d", test.k, total, len(toyExamples))
+		}
+		if len(cv.Categories) != len(db) || cv.MeanAccuracy < 0 || cv.MeanAccuracy > 1 {
+			t.Errorf("k %d: %d categories scored and mean accuracy %v", test.k, len(cv.Categories), cv.MeanAccuracy)
+		}
+	}
+}
@@ -0,0 +1,195 @@
+package functions
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//Example is a sentence of a database, its category and the line it was written on
+type Example struct {
+	Text     string
+	Category string
+	Line     int
+	//Variants made by augment, that are only trained with and never used to evaluate
+	Generated bool
+	//The sentence a generated example was made from, empty when it is not known
+	Source string
+}
+
+//The tag after the category of the generated examples on the sentences files, "[augmented: sentence]"
+//with the sentence it was made from, or only "[augmented]" when it is not known
+const AUGMENTED_TAG = "[augmented]"
+
+//ParseErrors are every malformed line of a database, in the order they were found
+type ParseErrors []*ParseError
+
+func (e ParseErrors) Error() string {
+	lines := make([]string, len(e))
+	for i, err := range e {
+		lines[i] = err.Error()
+	}
+	return strings.Join(lines, "\n")
+}
+
+//This function reads a database file of sentences and their categories, one on every line:
+//
+//	//Comments and blank lines are ignored
+//	#how are you? (greeting)
+//	#I want a pizza (with cheese) (food,order,pizza)
+//	#how r you? (greeting) [augmented: how are you?]
+//
+//The category is the last parenthesis of the line, so the sentence can have its own,
+//only followed by the tag of the variants made by augment. There is one example on every line,
+//the old databases with all of them on the same line are an error, not a long sentence.
+//A backslash escapes "\", "#", "(" and ")". Every malformed line is a *ParseError, they
+//are all returned together as ParseErrors with the examples of the lines that were fine
+func ScanPhrases(path string) ([]Example, error) {
+	//We open the database file
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	//No matter what we close it
+	defer file.Close()
+	return ParsePhrases(file, path)
+}
+
+//This function reads a database of sentences and their categories like ScanPhrases does,
+//the name is the one the errors use for the file
+func ParsePhrases(r io.Reader, name string) ([]Example, error) {
+	var examples []Example
+	var errs ParseErrors
+	//We scan the file line by line, with no limit on the length of a line
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	number := 0
+	for scanner.Scan() {
+		number++
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		//Editors on Windows start UTF-8 files with a byte order mark
+		if number == 1 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		example, err := parsePhrase(line)
+		if err != nil {
+			err.File, err.Line, err.Text = name, number, line
+			errs = append(errs, err)
+			continue
+		}
+		if example != nil {
+			example.Line = number
+			examples = append(examples, *example)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return examples, err
+	}
+	if len(errs) > 0 {
+		return examples, errs
+	}
+	return examples, nil
+}
+
+//This function reads a line of a database, it gets nil for comments and blank lines
+func parsePhrase(line string) (*Example, *ParseError) {
+	//Columns count characters from 1, like editors do
+	column := func(offset int) int {
+		return utf8.RuneCountInString(line[:offset]) + 1
+	}
+	if !utf8.ValidString(line) {
+		offset := 0
+		for offset < len(line) {
+			r, size := utf8.DecodeRuneInString(line[offset:])
+			if r == utf8.RuneError && size <= 1 {
+				break
+			}
+			offset += size
+		}
+		return nil, &ParseError{Column: column(offset), Msg: "invalid UTF-8"}
+	}
+	start := len(line) - len(strings.TrimLeftFunc(line, unicode.IsSpace))
+	rest := strings.TrimRightFunc(line[start:], unicode.IsSpace)
+	if rest == "" || strings.HasPrefix(rest, "//") {
+		return nil, nil
+	}
+	if rest[0] != '#' {
+		return nil, &ParseError{Column: column(start), Msg: `a sentence must start with "#"`}
+	}
+	generated, source := false, ""
+	if tag := strings.LastIndex(rest, AUGMENTED_TAG[:len(AUGMENTED_TAG)-1]); tag > 0 && strings.HasSuffix(rest, "]") {
+		inside := rest[tag+len(AUGMENTED_TAG)-1 : len(rest)-1]
+		if inside == "" || inside[0] == ':' {
+			generated, source = true, strings.TrimSpace(strings.TrimPrefix(inside, ":"))
+			rest = strings.TrimRightFunc(rest[:tag], unicode.IsSpace)
+		}
+	}
+
+	//Unescape the line, remembering where the last "(" and the last two ")" are, and the first "#" after the one starting it
+	var text []rune
+	var offsets []int
+	open, close, previous, hash := -1, -1, -1, -1
+	end := start + len(rest)
+	for offset := start + 1; offset < end; {
+		r, size := utf8.DecodeRuneInString(line[offset:])
+		if r == '\\' {
+			if offset+size == end {
+				return nil, &ParseError{Column: column(offset), Msg: "backslash at the end of the line"}
+			}
+			escaped, n := utf8.DecodeRuneInString(line[offset+size:])
+			switch escaped {
+			case '\\', '#', '(', ')':
+			default:
+				return nil, &ParseError{Column: column(offset), Msg: fmt.Sprintf("unknown escape \\%c", escaped)}
+			}
+			text, offsets = append(text, escaped), append(offsets, offset)
+			offset += size + n
+			continue
+		}
+		switch r {
+		case '(':
+			open = len(text)
+		case ')':
+			close, previous = len(text), close
+		case '#':
+			if hash < 0 {
+				hash = len(text)
+			}
+		}
+		text, offsets = append(text, r), append(offsets, offset)
+		offset += size
+	}
+
+	//A "#" right after a category starts another example, the sentences must be one on every line
+	if hash >= 0 {
+		before := strings.TrimRightFunc(string(text[:hash]), unicode.IsSpace)
+		if strings.HasSuffix(before, ")") {
+			return nil, &ParseError{Column: column(offsets[hash]), Msg: "another example on the same line, write one example per line"}
+		}
+		return nil, &ParseError{Column: column(offsets[hash]), Msg: `unescaped "#" inside the sentence, write it as \#`}
+	}
+	//The line must end with the category between parenthesis
+	if close < 0 || close != len(text)-1 {
+		return nil, &ParseError{Column: column(end), Msg: "missing (category) at the end of the line"}
+	}
+	if open < 0 {
+		return nil, &ParseError{Column: column(offsets[close]), Msg: `missing "(" before the category`}
+	}
+	category := strings.TrimSpace(string(text[open+1 : close]))
+	if category == "" {
+		return nil, &ParseError{Column: column(offsets[open]), Msg: "empty category"}
+	}
+	if previous > open {
+		return nil, &ParseError{Column: column(offsets[previous]), Msg: `unexpected ")" inside the category`}
+	}
+	sentence := strings.TrimSpace(string(text[:open]))
+	//Only the sentences we don't have an answer for can be empty
+	if sentence == "" && category != "noanswer" {
+		return nil, &ParseError{Column: column(start), Msg: "empty sentence"}
+	}
+	return &Example{Text: sentence, Category: category, Generated: generated, Source: source}, nil
+}
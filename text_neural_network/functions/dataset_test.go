@@ -0,0 +1,101 @@
+package functions
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParsePhrase(t *testing.T) {
+	tests := []struct {
+		line string
+		//The example read, nil for comments and blank lines
+		want *Example
+		//Column and part of the message of the error
+		column int
+		err    string
+	}{
+		{"#hola (greeting)", &Example{Text: "hola", Category: "greeting"}, 0, ""},
+		{"   #hola   ( greeting )  ", &Example{Text: "hola", Category: "greeting"}, 0, ""},
+		{"", nil, 0, ""},
+		{"   ", nil, 0, ""},
+		{"  //#hola (greeting)", nil, 0, ""},
+		{"#I want a pizza (with cheese) (food)", &Example{Text: "I want a pizza (with cheese)", Category: "food"}, 0, ""},
+		{`#C\# \\ \(a\) (lang)`, &Example{Text: `C# \ (a)`, Category: "lang"}, 0, ""},
+		{"# (noanswer)", &Example{Text: "", Category: "noanswer"}, 0, ""},
+		{"#how r you? (greeting) [augmented: how are you?]", &Example{Text: "how r you?", Category: "greeting", Generated: true, Source: "how are you?"}, 0, ""},
+		{"#hi (greeting) [augmented]", &Example{Text: "hi", Category: "greeting", Generated: true}, 0, ""},
+		{"hola (greeting)", nil, 1, `must start with "#"`},
+		{"  hola (greeting)", nil, 3, `must start with "#"`},
+		{"#hola greeting", nil, 15, "missing (category)"},
+		{"#hola (greeting) adios", nil, 23, "missing (category)"},
+		{"#hi (greeting) [other]", nil, 23, "missing (category)"},
+		{"#hola greeting)", nil, 15, `missing "("`},
+		{"#hola ()", nil, 7, "empty category"},
+		{"#hola (a)b)", nil, 9, `unexpected ")"`},
+		{"# (greeting)", nil, 1, "empty sentence"},
+		{`#a \x (b)`, nil, 4, `unknown escape \x`},
+		{`#a (b) \`, nil, 8, "backslash at the end"},
+		//The old databases had every example on the same line
+		{"#hola (greeting) #adios (goodbye)", nil, 18, "one example per line"},
+		{"#C# (lang)", nil, 3, `unescaped "#"`},
+		//Columns count characters, not bytes
+		{"#ñandú (bird", nil, 13, "missing (category)"},
+		{"#ñandú # (bird)", nil, 8, `unescaped "#"`},
+		{"#ab\xff (x)", nil, 4, "invalid UTF-8"},
+	}
+	for _, test := range tests {
+		got, err := parsePhrase(test.line)
+		if test.err != "" {
+			if err == nil || err.Column != test.column || !strings.Contains(err.Msg, test.err) {
+				t.Errorf("parsePhrase(%q) error = %+v, want column %d %q", test.line, err, test.column, test.err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePhrase(%q) error = %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("parsePhrase(%q) = %+v, want %+v", test.line, got, test.want)
+		}
+	}
+}
+
+func TestParsePhrases(t *testing.T) {
+	content := "\uFEFF#hola (greeting)\r\n" +
+		"//comment\n" +
+		"\n" +
+		"hola (greeting)\n" +
+		"#adios (goodbye)\n" +
+		"#pizza (food) #sushi (food)\n"
+	examples, err := ParsePhrases(strings.NewReader(content), "chatss.txt")
+	//The lines that are fine are read, with their line numbers
+	want := []Example{{Text: "hola", Category: "greeting", Line: 1}, {Text: "adios", Category: "goodbye", Line: 5}}
+	if !reflect.DeepEqual(examples, want) {
+		t.Errorf("examples %+v, want %+v", examples, want)
+	}
+	var errs ParseErrors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("error = %v, want two ParseErrors", err)
+	}
+	tests := []struct {
+		err  *ParseError
+		want string
+	}{
+		{errs[0], `chatss.txt:4:1: a sentence must start with "#"`},
+		{errs[1], "chatss.txt:6:15: another example on the same line, write one example per line"},
+	}
+	for _, test := range tests {
+		if got := test.err.Error(); got != test.want {
+			t.Errorf("error %q, want %q", got, test.want)
+		}
+	}
+	if errs[1].Text != "#pizza (food) #sushi (food)" {
+		t.Errorf("the error has the line %q", errs[1].Text)
+	}
+	if _, err := ScanPhrases("missing.txt"); err == nil {
+		t.Error("ScanPhrases of a missing file didn't fail")
+	}
+}
@@ -0,0 +1,53 @@
+package functions
+
+//This function gets the edit distance between two words: the insertions, deletions, substitutions
+//and swaps of two neighbour letters needed to turn one into the other (Damerau-Levenshtein).
+//Letters can be edited again after a swap, so it is a metric and the BK-tree can prune with it
+func editDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	infinity := len(s) + len(t)
+	//The table has an extra row and column of infinity before the usual first ones
+	d := make([][]int, len(s)+2)
+	for i := range d {
+		d[i] = make([]int, len(t)+2)
+		d[i][0] = infinity
+		if i > 0 {
+			d[i][1] = i - 1
+		}
+	}
+	for j := 1; j < len(t)+2; j++ {
+		d[0][j] = infinity
+		d[1][j] = j - 1
+	}
+	//Last row of a of every letter seen so far
+	last_row := make(map[rune]int)
+	for i := 1; i <= len(s); i++ {
+		//Last column of b on this row where the letters were equal
+		last_col := 0
+		for j := 1; j <= len(t); j++ {
+			k, l := last_row[t[j-1]], last_col
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost, last_col = 0, j
+			}
+			d[i+1][j+1] = min3(d[i][j]+cost, d[i+1][j]+1, d[i][j+1]+1)
+			//Swap the letters of rows k and i, editing everything between them
+			if swap := d[k][l] + (i - k - 1) + 1 + (j - l - 1); swap < d[i+1][j+1] {
+				d[i+1][j+1] = swap
+			}
+		}
+		last_row[s[i-1]] = i
+	}
+	return d[len(s)+1][len(t)+1]
+}
+
+//This function gets the smallest of three numbers
+func min3(a int, b int, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -0,0 +1,35 @@
+package functions
+
+import "testing"
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"hola", "hola", 0},
+		{"", "hola", 4},
+		{"hola", "ola", 1},
+		{"hola", "holas", 1},
+		{"hola", "hila", 1},
+		//A swap of two neighbour letters is a single edit
+		{"hola", "hloa", 1},
+		{"pizza", "pziza", 1},
+		//A swapped pair can be edited again: ca, ac, abc
+		{"ca", "abc", 2},
+		{"acab", "acb", 1},
+		{"kitten", "sitting", 3},
+		//Letters, not bytes
+		{"niño", "nino", 1},
+		{"días", "dias", 1},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.a, test.b, got, test.want)
+		}
+		if got := editDistance(test.b, test.a); got != test.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", test.b, test.a, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+)
+
+//Errors of the files the bot is built from, check them with errors.Is
+var (
+	ErrModelNotFound   = errors.New("model not found")
+	ErrCorruptModel    = errors.New("corrupt model")
+	ErrIntentsNotFound = errors.New("intents not found")
+	ErrCorruptIntents  = errors.New("corrupt intents")
+)
+
+//Errors of answering a sentence
+var (
+	ErrEmptySentence = errors.New("empty sentence")
+	ErrNoResponse    = errors.New("no response")
+)
+
+//ParseError is a line of a sentences database that can't be read, and the column
+//where the problem is, both counted from 1
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	//The whole line
+	Text string
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d:%d: %s", e.File, e.Line, e.Column, e.Msg)
+}
@@ -0,0 +1,145 @@
+package functions
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+)
+
+//Report is the evaluation of a model over a database of labeled sentences
+type Report struct {
+	Accuracy float64
+	Correct  int
+	Total    int
+	//Average of the precision, recall and F1 of the categories that were tested or predicted,
+	//all of them weigh the same
+	MacroPrecision float64
+	MacroRecall    float64
+	MacroF1        float64
+	Categories     []CategoryReport
+	//Confusion[i][j] counts the sentences of category Labels[i] classified as Labels[j]
+	Labels    []string
+	Confusion [][]int
+}
+
+//CategoryReport is the evaluation of a single category
+type CategoryReport struct {
+	Category  string
+	Precision float64
+	Recall    float64
+	F1        float64
+	//Number of test sentences of the category
+	Support int
+}
+
+//This function classifies every sentence of a labeled database, in the same way SetDb
+//returns it, and compares the categories found with the expected ones
+func Evaluate(model *Model, db map[string][]string) Report {
+	//Classify every sentence first, a sentence that is not understood is noanswer even if the model has no such category
+	var expected, predicted []string
+	for category, sentences := range db {
+		for _, sentence := range sentences {
+			expected = append(expected, category)
+			predicted = append(predicted, model.Category(sentence, false).Key)
+		}
+	}
+	//The labels are every category of the model, of the test database and of the predictions
+	index := make(map[string]int)
+	var labels []string
+	for _, category := range model.Categories {
+		index[category] = -1
+	}
+	for _, category := range predicted {
+		index[category] = -1
+	}
+	for category := range db {
+		index[category] = -1
+	}
+	for category := range index {
+		labels = append(labels, category)
+	}
+	sort.Strings(labels)
+	for i, category := range labels {
+		index[category] = i
+	}
+	confusion := make([][]int, len(labels))
+	for i := range confusion {
+		confusion[i] = make([]int, len(labels))
+	}
+	var report Report
+	for i := range expected {
+		confusion[index[expected[i]]][index[predicted[i]]]++
+		report.Total++
+		if predicted[i] == expected[i] {
+			report.Correct++
+		}
+	}
+	if report.Total > 0 {
+		report.Accuracy = float64(report.Correct) / float64(report.Total)
+	}
+	report.Labels, report.Confusion = labels, confusion
+	averaged := 0
+	for i, category := range labels {
+		c := CategoryReport{Category: category}
+		//Sentences classified as the category, and sentences that are of the category
+		predicted := 0
+		for j := range labels {
+			predicted += confusion[j][i]
+			c.Support += confusion[i][j]
+		}
+		hits := confusion[i][i]
+		if predicted > 0 {
+			c.Precision = float64(hits) / float64(predicted)
+		}
+		if c.Support > 0 {
+			c.Recall = float64(hits) / float64(c.Support)
+		}
+		if c.Precision+c.Recall > 0 {
+			c.F1 = 2 * c.Precision * c.Recall / (c.Precision + c.Recall)
+		}
+		report.Categories = append(report.Categories, c)
+		//Categories of the model that never showed up don't count on the averages
+		if c.Support > 0 || predicted > 0 {
+			report.MacroPrecision += c.Precision
+			report.MacroRecall += c.Recall
+			report.MacroF1 += c.F1
+			averaged++
+		}
+	}
+	if averaged > 0 {
+		report.MacroPrecision /= float64(averaged)
+		report.MacroRecall /= float64(averaged)
+		report.MacroF1 /= float64(averaged)
+	}
+	return report
+}
+
+//This function writes the report as tables, first the metrics of every category and then the confusion matrix
+func (r Report) Print(out io.Writer) {
+	fmt.Fprintf(out, "Accuracy: %.4f (%d/%d)\n\n", r.Accuracy, r.Correct, r.Total)
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tcategory\tprecision\trecall\tf1\tsupport")
+	for i, c := range r.Categories {
+		fmt.Fprintf(w, "%d\t%s\t%.4f\t%.4f\t%.4f\t%d\n", i, c.Category, c.Precision, c.Recall, c.F1, c.Support)
+	}
+	fmt.Fprintf(w, "\tmacro average\t%.4f\t%.4f\t%.4f\t%d\n", r.MacroPrecision, r.MacroRecall, r.MacroF1, r.Total)
+	w.Flush()
+
+	//The columns are numbered like the categories above, the names are too long for a header
+	fmt.Fprintln(out, "\nConfusion matrix (rows: expected category, columns: predicted category)")
+	w = tabwriter.NewWriter(out, 0, 0, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprint(w, "\t")
+	for j := range r.Labels {
+		fmt.Fprintf(w, "%d\t", j)
+	}
+	fmt.Fprintln(w)
+	for i, row := range r.Confusion {
+		fmt.Fprintf(w, "%d\t", i)
+		for _, n := range row {
+			fmt.Fprintf(w, "%d\t", n)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+}
@@ -0,0 +1,83 @@
+package functions
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//This function gets a model that reads hola as greeting, adios as goodbye and pizza as food.
+//The tanh output is zero for a sentence without those words, so it is noanswer
+func keywordModel() *Model {
+	return &Model{
+		Words:         []string{"hola", "adios", "pizza"},
+		Categories:    []string{"food", "goodbye", "greeting"},
+		Weights:       []*mat.Dense{mat.NewDense(3, 3, []float64{0, 0, 5, 0, 5, 0, 5, 0, 0})},
+		Activations:   []string{TANH},
+		Preprocessing: DefaultPreprocessing(),
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		db   map[string][]string
+		want Report
+	}{
+		{
+			name: "every sentence right",
+			db:   map[string][]string{"greeting": {"hola"}, "goodbye": {"adios amigo"}},
+			//food has no sentences and no predictions, it doesn't count on the averages
+			want: Report{Accuracy: 1, Correct: 2, Total: 2, MacroPrecision: 1, MacroRecall: 1, MacroF1: 1,
+				Categories: []CategoryReport{{"food", 0, 0, 0, 0}, {"goodbye", 1, 1, 1, 1}, {"greeting", 1, 1, 1, 1}},
+				Labels:     []string{"food", "goodbye", "greeting"},
+				Confusion:  [][]int{{0, 0, 0}, {0, 1, 0}, {0, 0, 1}}},
+		},
+		{
+			name: "a mistake and a sentence not understood",
+			db:   map[string][]string{"greeting": {"hola", "hola amigo", "adios"}, "goodbye": {"adios", "adios amigo"}, "food": {"pizza", "nada"}},
+			want: Report{Accuracy: 5.0 / 7, Correct: 5, Total: 7,
+				MacroPrecision: (1 + 2.0/3 + 1 + 0) / 4, MacroRecall: (0.5 + 1 + 2.0/3 + 0) / 4, MacroF1: (2.0/3 + 0.8 + 0.8 + 0) / 4,
+				Categories: []CategoryReport{{"food", 1, 0.5, 2.0 / 3, 2}, {"goodbye", 2.0 / 3, 1, 0.8, 2}, {"greeting", 1, 2.0 / 3, 0.8, 3}, {"noanswer", 0, 0, 0, 0}},
+				Labels:     []string{"food", "goodbye", "greeting", "noanswer"},
+				Confusion:  [][]int{{1, 0, 0, 1}, {0, 2, 0, 0}, {0, 1, 2, 0}, {0, 0, 0, 0}}},
+		},
+		{
+			name: "no sentences",
+			db:   map[string][]string{},
+			want: Report{Categories: []CategoryReport{{"food", 0, 0, 0, 0}, {"goodbye", 0, 0, 0, 0}, {"greeting", 0, 0, 0, 0}},
+				Labels:    []string{"food", "goodbye", "greeting"},
+				Confusion: [][]int{{0, 0, 0}, {0, 0, 0}, {0, 0, 0}}},
+		},
+	}
+	for _, test := range tests {
+		got := Evaluate(keywordModel(), test.db)
+		if !reflect.DeepEqual(got.Labels, test.want.Labels) || !reflect.DeepEqual(got.Confusion, test.want.Confusion) {
+			t.Errorf("%s: labels %v and confusion %v, want %v and %v", test.name, got.Labels, got.Confusion, test.want.Labels, test.want.Confusion)
+		}
+		if got.Correct != test.want.Correct || got.Total != test.want.Total || !near(got.Accuracy, test.want.Accuracy) {
+			t.Errorf("%s: accuracy %v (%d/%d), want %v (%d/%d)", test.name, got.Accuracy, got.Correct, got.Total, test.want.Accuracy, test.want.Correct, test.want.Total)
+		}
+		if !near(got.MacroPrecision, test.want.MacroPrecision) || !near(got.MacroRecall, test.want.MacroRecall) || !near(got.MacroF1, test.want.MacroF1) {
+			t.Errorf("%s: macro averages %v %v %v, want %v %v %v", test.name, got.MacroPrecision, got.MacroRecall, got.MacroF1,
+				test.want.MacroPrecision, test.want.MacroRecall, test.want.MacroF1)
+		}
+		if len(got.Categories) != len(test.want.Categories) {
+			t.Errorf("%s: %d categories, want %d", test.name, len(got.Categories), len(test.want.Categories))
+			continue
+		}
+		for i, c := range got.Categories {
+			want := test.want.Categories[i]
+			if c.Category != want.Category || c.Support != want.Support || !near(c.Precision, want.Precision) || !near(c.Recall, want.Recall) || !near(c.F1, want.F1) {
+				t.Errorf("%s: %+v, want %+v", test.name, c, want)
+			}
+		}
+	}
+}
+
+//This function tells if two metrics are the same, but for rounding
+func near(a, b float64) bool {
+	return math.Abs(a-b) < 1e-12
+}
@@ -0,0 +1,156 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//Names of the features the network can take from the words of a sentence, as saved on the model file
+const (
+	BINARY = "binary"
+	TF     = "tf"
+	TFIDF  = "tfidf"
+)
+
+//FeatureExtractor turns how many times every word of the vocabulary is on a sentence into the input of the network
+type FeatureExtractor interface {
+	//Fit learns what the extractor needs from the counts of the training sentences, a row for every sentence
+	Fit(counts *mat.Dense)
+	//Transform gets the features of every row of counts, once the extractor learned from the training sentences
+	Transform(counts *mat.Dense) *mat.Dense
+	//State gets everything the extractor learned, to save it on the model
+	State() Features
+}
+
+//Features is a feature extractor as saved on the model file
+type Features struct {
+	Name string
+	//Inverse document frequency of every word of the vocabulary, only for tfidf
+	IDF []float64 `json:",omitempty"`
+}
+
+//This function gets a new feature extractor, binary if the name is empty
+func NewFeatureExtractor(name string) (FeatureExtractor, error) {
+	switch name {
+	case BINARY, "":
+		return binaryFeatures{}, nil
+	case TF:
+		return tfFeatures{}, nil
+	case TFIDF:
+		return &tfidfFeatures{}, nil
+	}
+	return nil, fmt.Errorf("unknown features %q, they must be binary, tf or tfidf", name)
+}
+
+//This function gets a feature extractor back from its saved state, with what it learned
+func RestoreFeatures(state Features) (FeatureExtractor, error) {
+	if state.Name == TFIDF {
+		return &tfidfFeatures{idf: state.IDF}, nil
+	}
+	return NewFeatureExtractor(state.Name)
+}
+
+//This function checks the features are known, and that tfidf has a weight for every word
+func (f Features) validate(words int) error {
+	if _, err := RestoreFeatures(f); err != nil {
+		return err
+	}
+	if f.Name == TFIDF && len(f.IDF) != words {
+		return fmt.Errorf("the tfidf features have %d weights for %d words", len(f.IDF), words)
+	}
+	return nil
+}
+
+//binaryFeatures is the bag of words: a one for every word on the sentence, no matter how many times it is there
+type binaryFeatures struct{}
+
+func (binaryFeatures) Fit(*mat.Dense) {}
+
+func (binaryFeatures) Transform(counts *mat.Dense) *mat.Dense {
+	return mapRows(counts, func(row []float64) {
+		for j, count := range row {
+			if count > 0 {
+				row[j] = 1
+			}
+		}
+	})
+}
+
+func (binaryFeatures) State() Features {
+	return Features{Name: BINARY}
+}
+
+//tfFeatures is the term frequency: the times every word is on the sentence over the words of the sentence,
+//so a word repeated counts more and a word on a long sentence counts less
+type tfFeatures struct{}
+
+func (tfFeatures) Fit(*mat.Dense) {}
+
+func (tfFeatures) Transform(counts *mat.Dense) *mat.Dense {
+	return mapRows(counts, termFrequency)
+}
+
+func (tfFeatures) State() Features {
+	return Features{Name: TF}
+}
+
+//tfidfFeatures is the term frequency times the inverse document frequency of every word, learned from
+//the training sentences. The words on many sentences say little about the category, so they count less
+type tfidfFeatures struct {
+	idf []float64
+}
+
+func (f *tfidfFeatures) Fit(counts *mat.Dense) {
+	r, c := counts.Dims()
+	f.idf = make([]float64, c)
+	for j := 0; j < c; j++ {
+		//Sentences that have the word
+		df := 0
+		for i := 0; i < r; i++ {
+			if counts.At(i, j) > 0 {
+				df++
+			}
+		}
+		//Smoothed as if there was one more sentence with every word, so no weight is infinite or zero
+		f.idf[j] = math.Log(float64(1+r)/float64(1+df)) + 1
+	}
+}
+
+func (f *tfidfFeatures) Transform(counts *mat.Dense) *mat.Dense {
+	return mapRows(counts, func(row []float64) {
+		termFrequency(row)
+		for j := range row {
+			row[j] *= f.idf[j]
+		}
+	})
+}
+
+func (f *tfidfFeatures) State() Features {
+	return Features{Name: TFIDF, IDF: f.idf}
+}
+
+//This function divides the counts of a sentence by its number of words, a sentence without words stays at zero
+func termFrequency(row []float64) {
+	var total float64
+	for _, count := range row {
+		total += count
+	}
+	if total == 0 {
+		return
+	}
+	for j := range row {
+		row[j] /= total
+	}
+}
+
+//This function gets a copy of a matrix with every row changed by a function
+func mapRows(m *mat.Dense, change func(row []float64)) *mat.Dense {
+	output := mat.DenseCopyOf(m)
+	r, _ := output.Dims()
+	for i := 0; i < r; i++ {
+		change(output.RawRowView(i))
+	}
+	return output
+}
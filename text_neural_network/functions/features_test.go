@@ -0,0 +1,137 @@
+package functions
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestFeatureExtractors(t *testing.T) {
+	//Three sentences: the first word twice and the second once, the first word once, and no known words
+	counts := mat.NewDense(3, 3, []float64{2, 1, 0, 1, 0, 0, 0, 0, 0})
+	//Smoothed inverse document frequencies, ln((1+n)/(1+df))+1 with the first word on two sentences,
+	//the second on one and the third on none
+	idf := []float64{math.Log(4.0/3) + 1, math.Log(2) + 1, math.Log(4) + 1}
+	tests := []struct {
+		name string
+		want []float64
+	}{
+		{BINARY, []float64{1, 1, 0, 1, 0, 0, 0, 0, 0}},
+		{"", []float64{1, 1, 0, 1, 0, 0, 0, 0, 0}},
+		{TF, []float64{2.0 / 3, 1.0 / 3, 0, 1, 0, 0, 0, 0, 0}},
+		{TFIDF, []float64{2.0 / 3 * idf[0], 1.0 / 3 * idf[1], 0, idf[0], 0, 0, 0, 0, 0}},
+	}
+	for _, test := range tests {
+		extractor, err := NewFeatureExtractor(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		extractor.Fit(counts)
+		got := extractor.Transform(counts).RawMatrix().Data
+		for i := range got {
+			if !near(got[i], test.want[i]) {
+				t.Errorf("%q: features %v, want %v", test.name, got, test.want)
+				break
+			}
+		}
+		//The counts are left as they were
+		if data := counts.RawMatrix().Data; !reflect.DeepEqual(data, []float64{2, 1, 0, 1, 0, 0, 0, 0, 0}) {
+			t.Fatalf("%q: the counts changed to %v", test.name, data)
+		}
+		//An extractor restored from its state gets the same features
+		restored, err := RestoreFeatures(extractor.State())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again := restored.Transform(counts).RawMatrix().Data; !reflect.DeepEqual(again, got) {
+			t.Errorf("%q: restored features %v, want %v", test.name, again, got)
+		}
+	}
+	extractor, _ := NewFeatureExtractor(TFIDF)
+	extractor.Fit(counts)
+	if got := extractor.State(); got.Name != TFIDF || len(got.IDF) != 3 || !near(got.IDF[0], idf[0]) || !near(got.IDF[1], idf[1]) || !near(got.IDF[2], idf[2]) {
+		t.Errorf("tfidf state %+v, want the weights %v", got, idf)
+	}
+	if _, err := NewFeatureExtractor("word2vec"); err == nil {
+		t.Error("NewFeatureExtractor of unknown features didn't fail")
+	}
+}
+
+func TestBinarize(t *testing.T) {
+	//Binarize is the binary features of the counts, a word twice on a sentence is still a one
+	db := map[string][]string{"food": {"pizza pizza", "quiero pizza"}, "greeting": {"hola"}}
+	words, categories := []string{"pizza", "quiero", "hola"}, []string{"food", "greeting"}
+	counts, _ := CountWords(db, words, categories, DefaultPreprocessing())
+	if want := mat.NewDense(3, 3, []float64{2, 0, 0, 1, 1, 0, 0, 0, 1}); !mat.Equal(counts, want) {
+		t.Errorf("CountWords = %v, want %v", mat.Formatted(counts), mat.Formatted(want))
+	}
+	x, y := Binarize(db, words, categories, DefaultPreprocessing())
+	if want := mat.NewDense(3, 3, []float64{1, 0, 0, 1, 1, 0, 0, 0, 1}); !mat.Equal(x, want) {
+		t.Errorf("Binarize = %v, want %v", mat.Formatted(x), mat.Formatted(want))
+	}
+	if want := mat.NewDense(3, 2, []float64{1, 0, 1, 0, 0, 1}); !mat.Equal(y, want) {
+		t.Errorf("Binarize output = %v, want %v", mat.Formatted(y), mat.Formatted(want))
+	}
+}
+
+func TestFeaturesValidate(t *testing.T) {
+	tests := []struct {
+		features Features
+		err      bool
+	}{
+		{Features{Name: BINARY}, false},
+		{Features{Name: TF}, false},
+		{Features{Name: TFIDF, IDF: []float64{1, 2, 3}}, false},
+		{Features{Name: TFIDF, IDF: []float64{1, 2}}, true},
+		{Features{Name: TFIDF}, true},
+		{Features{Name: "word2vec"}, true},
+	}
+	for _, test := range tests {
+		if err := test.features.validate(3); (err != nil) != test.err {
+			t.Errorf("validate(%+v) = %v, want error %t", test.features, err, test.err)
+		}
+	}
+}
+
+func TestTrainFeatures(t *testing.T) {
+	db, x, y, words, categories := toyData()
+	for _, name := range []string{BINARY, TF, TFIDF} {
+		config := toyConfig()
+		config.Features = name
+		model, err := Train(x, y, config, words, categories)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if model.Features.Name != name {
+			t.Errorf("%s: the model has the features %q", name, model.Features.Name)
+		}
+		checkLearned(t, model, db)
+		//The weights tfidf learned from the training sentences are saved with the model
+		file := filepath.Join(t.TempDir(), "model.json")
+		if err := SaveFile(file, model); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(loaded.Features, model.Features) {
+			t.Errorf("%s: loaded the features %+v, want %+v", name, loaded.Features, model.Features)
+		}
+		if name == TFIDF {
+			extractor := &tfidfFeatures{}
+			extractor.Fit(x)
+			if !reflect.DeepEqual(model.Features.IDF, extractor.idf) || len(model.Features.IDF) != len(words) {
+				t.Errorf("%s: the model has the weights %v, want %v", name, model.Features.IDF, extractor.idf)
+			}
+		}
+	}
+	config := toyConfig()
+	config.Features = "word2vec"
+	if _, err := Train(x, y, config, words, categories); err == nil {
+		t.Error("Train with unknown features didn't fail")
+	}
+}
@@ -4,340 +4,498 @@ import (
 	"bufio"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"math"
 	"math/rand"
 	"os"
-	"regexp"
 	"sort"
 	"strings"
+	"time"
 	"unicode"
 
-	"golang.org/x/text/transform"
-	"golang.org/x/text/unicode/norm"
 	"gonum.org/v1/gonum/blas/blas64"
 	"gonum.org/v1/gonum/mat"
 )
 
+//Score a category needs for a sentence to be understood, when the model doesn't have its own
 var ERROR_THRESHOLD = 0.2
 
-func LoadFile(file string) (*mat.Dense, *mat.Dense, []string, []string) {
+//This function loads a model file. The file is checked against its checksum and the model
+//against itself, so a corrupt or edited file is an error instead of a wrong network.
+//Files saved before the format had a version are migrated
+func LoadFile(file string) (*Model, error) {
 	// load our calculated synapse values
-	jsonFile, err := os.Open(file)
-	// if we os.Open returns an error then handle it
+	content, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrModelNotFound, file)
+	}
 	if err != nil {
-		fmt.Println(err)
+		return nil, err
 	}
-	fmt.Println("Successfully Opened model.json")
-	defer jsonFile.Close()
-
-	//read our opened json file
-	byteValue, _ := ioutil.ReadAll(jsonFile)
-	//Initialize map
-	var data synapse
-	err = json.Unmarshal([]byte(byteValue), &data)
+	data, err := openModel(content, isBinary(file))
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrCorruptModel, file, err)
+	}
+	model, err := data.load()
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w %s: %v", ErrCorruptModel, file, err)
 	}
+	return model, nil
+}
 
-	s_0 := data.Synapse_0
-	s_1 := data.Synapse_1
-	synapse_0 := mat.NewDense(s_0.Rows, s_0.Cols, s_0.Data)
-	synapse_1 := mat.NewDense(s_1.Rows, s_1.Cols, s_1.Data)
-	words := data.Words
-	categories := data.Categories
+//This function gets the model stored on the data of a model file, migrating the old layouts
+//and checking every matrix fits with the next one
+func (data synapse) load() (*Model, error) {
+	//Models saved with the two fixed layers keep their weights on Synapse_0 and Synapse_1
+	if len(data.Synapses) == 0 && data.Synapse_0 != nil && data.Synapse_1 != nil {
+		output := data.Output
+		//Models saved before the output option existed used sigmoid
+		if output == "" {
+			output = SIGMOID
+		}
+		data.Synapses = []blas64.General{*data.Synapse_0, *data.Synapse_1}
+		data.Activations = []string{SIGMOID, output}
+	}
+	//Models saved before the preprocessing was recorded used the default one
+	if len(data.Preprocessing.Steps) == 0 {
+		data.Preprocessing = DefaultPreprocessing()
+	}
+	//Models saved before the features could be chosen were binary
+	if data.Features.Name == "" {
+		data.Features.Name = BINARY
+	}
+	if err := data.validate(); err != nil {
+		return nil, err
+	}
 
-	return synapse_0, synapse_1, words, categories
+	model := &Model{
+		Activations: data.Activations,
+		Words:       data.Words,
+		Categories:  data.Categories,
+		Alpha:       data.Alpha,
+		Schedule:    data.Schedule,
+		Optimizer:   data.Optimizer,
+		Metrics:     data.Metrics,
+
+		Created:       data.Created,
+		DatasetSHA256: data.DatasetSHA256,
+		Config:        data.Config,
+		Preprocessing: data.Preprocessing,
+		Features:      data.Features,
+		MaxDistance:   data.MaxDistance,
+		Threshold:     data.Threshold,
+	}
+	for _, s := range data.Synapses {
+		model.Weights = append(model.Weights, mat.NewDense(s.Rows, s.Cols, s.Data))
+	}
+	return model, nil
 }
 
-func LoadIntens(file string) Outmost {
-	// load our intents file
-	jsonFile, err := os.Open(file)
-	// if we os.Open returns an error then handle it
+//This function saves the weights, activations, words and categories of a model into a json file,
+//or a binary one if its extension is .gob or .bin, with the version of the format and the checksum of the model
+func SaveFile(file string, model *Model) error {
+	//Encode the data into a json
+	content, err := sealModel(newSynapse(model), isBinary(file))
 	if err != nil {
-		fmt.Println(err)
+		return err
 	}
-	fmt.Println("Successfully Opened model.json")
-	defer jsonFile.Close()
+	//Save the file, through a temporary one so a failed save keeps the model that was there
+	return writeFile(file, func(w io.Writer) error {
+		_, err := w.Write(content)
+		return err
+	})
+}
 
-	//read our opened json file
-	byteValue, _ := ioutil.ReadAll(jsonFile)
+//This function gets the data of a model to store it on a file
+func newSynapse(model *Model) synapse {
+	//Store the synapse matrixes values on data
+	data := synapse{
+		Activations: model.Activations,
+		Words:       model.Words,
+		Categories:  model.Categories,
+		Alpha:       model.Alpha,
+		Schedule:    model.Schedule,
+		Optimizer:   model.Optimizer,
+		Metrics:     model.Metrics,
+
+		Created:       model.Created,
+		DatasetSHA256: model.DatasetSHA256,
+		Config:        model.Config,
+		Preprocessing: model.Preprocessing,
+		Features:      model.Features,
+		MaxDistance:   model.MaxDistance,
+		Threshold:     model.Threshold,
+	}
+	for _, w := range model.Weights {
+		data.Synapses = append(data.Synapses, w.RawMatrix())
+	}
+	return data
+}
+
+//This function loads the responses of every category from the intents file,
+//or from a yaml dataset that has them with the examples
+func LoadIntens(file string) (map[string][]string, error) {
+	if reader, err := ReaderFor(file); err == nil {
+		if _, ok := reader.(yamlReader); ok {
+			return loadYamlIntents(file)
+		}
+	}
+	// load our intents file
+	byteValue, err := ioutil.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrIntentsNotFound, file)
+	}
+	if err != nil {
+		return nil, err
+	}
 	//Initialize data for storing the intents
 	var data Outmost
-	err = json.Unmarshal([]byte(byteValue), &data)
+	err = json.Unmarshal(byteValue, &data)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("%w %s: %v", ErrCorruptIntents, file, err)
 	}
-	return data
+	if data.Category == nil {
+		return nil, fmt.Errorf("%w %s: it has no \"category\" key with the responses", ErrCorruptIntents, file)
+	}
+	//Responses are keyed by the same category names SetDb finds on the database
+	return data.Category, nil
 }
 
-func Train(x *mat.Dense, y *mat.Dense, hidden int, alpha float64, epochs int, dropout bool, dropout_percent float64, words_db []string, categories []string) {
+//This function loads the responses of a yaml dataset
+func loadYamlIntents(file string) (map[string][]string, error) {
+	dataset, err := LoadDataset(file)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%w: %s", ErrIntentsNotFound, file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrCorruptIntents, file, err)
+	}
+	return dataset.Responses, nil
+}
+
+func Train(x *mat.Dense, y *mat.Dense, config TrainConfig, words_db []string, categories []string) (*Model, error) {
+	//Print the progress of the training, unless told to be quiet
+	printf := fmt.Printf
+	if config.Quiet {
+		printf = func(string, ...interface{}) (int, error) { return 0, nil }
+	}
+	//Every random number of the training comes from the seed, so the same seed trains the same model
+	rng := rand.New(rand.NewSource(config.Seed))
+	if config.Validation < 0 || config.Validation >= 1 {
+		return nil, fmt.Errorf("validation fraction must be between 0 and 1, got %v", config.Validation)
+	}
+	//Hold out some examples of every category to check the model on sentences it never trained with
+	var x_val, y_val *mat.Dense
+	if config.Validation > 0 {
+		train_rows, val_rows := stratifiedSplit(y, config.Validation, config.Sources, rng)
+		if len(val_rows) > 0 {
+			x_val, y_val = selectRows(x, val_rows), selectRows(y, val_rows)
+			x, y = selectRows(x, train_rows), selectRows(y, train_rows)
+		}
+	}
 	//We get the dimension of input x and y
 	rx, cx := x.Dims()
 	ry, cy := y.Dims()
-	fmt.Printf("Training with %v,  alpha: %f, dropout: %t\n", hidden, alpha, dropout)
-	fmt.Printf("Input matrix: %vx%v  Output matrix: %vx%v\n", rx, cx, ry, cy)
-
+	alpha := config.Alpha
+	optimizer, err := NewOptimizer(config.Optimizer)
+	if err != nil {
+		return nil, err
+	}
+	extractor, err := NewFeatureExtractor(config.Features)
+	if err != nil {
+		return nil, err
+	}
+	schedule := config.Schedule
+	//The cosine schedule lasts the whole training, unless told otherwise
+	if schedule.Name == COSINE && schedule.Epochs == 0 {
+		schedule.Epochs = config.Epochs
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	if config.Dropout && (config.DropoutPercent < 0 || config.DropoutPercent >= 1) {
+		return nil, fmt.Errorf("dropout percent must be between 0 and 1, got %v", config.DropoutPercent)
+	}
+	if config.Threshold < 0 || config.Threshold >= 1 {
+		return nil, fmt.Errorf("threshold must be between 0 and 1, got %v", config.Threshold)
+	}
+	output := SIGMOID
+	if config.Softmax {
+		output = SOFTMAX
+	}
+	//A batch size of 0, or bigger than the data, means full-batch gradient descent
+	batch_size := config.BatchSize
+	if batch_size <= 0 || batch_size > rx {
+		batch_size = rx
+	}
+	//Best weights found on the validation examples, and epochs since they were found
+	var best []*mat.Dense
+	wait := 0
+	first := 0
 	last_mean_error := float64(1)
-	//Randomly set data for the weights x
-	_, c1 := x.Dims()
-	data1 := make([]float64, c1*hidden)
-	for i := range data1 {
-		data1[i] = 2*rand.Float64() - 1
-	}
-	//Randomly set data for the weights y
-	_, c2 := y.Dims()
-	data2 := make([]float64, hidden*c2)
-	for i := range data2 {
-		data2[i] = 2*rand.Float64() - 1
-	}
-	//Set random weights for synapse_1 and synapse_0
-	synapse_0 := mat.NewDense(c1, hidden, data1)
-	synapse_1 := mat.NewDense(hidden, c2, data2)
-
-	for ep := 0; ep <= epochs; ep++ {
-		//Set input, layer 0
-		layer_0 := x
-		//Feed forward to layer 1
-		layer_0_prod := new(mat.Dense)
-		layer_0_prod.Product(layer_0, synapse_0)
-		layer_1 := sigmoid(layer_0_prod)
-
-		if dropout {
-			data3 := make([]float64, c1*hidden)
-			for i := range data3 {
-				r := rand.Float64()
-				data3[i] = r / r
-			}
-			ones := mat.NewDense(c1, hidden, data3)
-			layer_1.Product(layer_1, ones)
+	var model *Model
+	if cp := config.Resume; cp != nil {
+		//Continue from a checkpoint, with its weights, optimizer, learning rate and progress
+		if err := cp.compatible(words_db, categories); err != nil {
+			return nil, err
+		}
+		model, first, best, wait = cp.Model, cp.Epoch, cp.Best, cp.Wait
+		//The checkpoints saved before the error was kept don't have it
+		if cp.LastMeanError > 0 {
+			last_mean_error = cp.LastMeanError
+		}
+		alpha, schedule, output = model.Alpha, model.Schedule, model.Activations[len(model.Activations)-1]
+		if optimizer, err = RestoreOptimizer(model.Optimizer); err != nil {
+			return nil, err
 		}
-		//Forward propagation to layer 2
-		layer_2_prod := new(mat.Dense)
-		layer_2_prod.Product(layer_1, synapse_1)
-		layer_2 := sigmoid(layer_2_prod)
+		if extractor, err = RestoreFeatures(model.Features); err != nil {
+			return nil, err
+		}
+		printf("Resuming training from epoch %v\n", first)
+	} else {
+		//The features only learn from the training examples, never from the validation ones
+		extractor.Fit(x)
+		//Every hidden layer takes the previous layer as input, and the output layer takes the last hidden one
+		model = &Model{Words: words_db, Categories: categories, Alpha: alpha, Schedule: schedule, Preprocessing: config.preprocessing(), Features: extractor.State()}
+		inputs := cx
+		for _, layer := range config.Layers {
+			model.Weights = append(model.Weights, randomWeights(inputs, layer.Size, rng))
+			model.Activations = append(model.Activations, layer.Activation)
+			inputs = layer.Size
+		}
+		model.Weights = append(model.Weights, randomWeights(inputs, cy, rng))
+		model.Activations = append(model.Activations, output)
+	}
+	//The network takes the features of the words, not how many times they are on every sentence
+	x = extractor.Transform(x)
+	if x_val != nil {
+		x_val = extractor.Transform(x_val)
+	}
+	weights := model.Weights
+	printf("Training with %v,  alpha: %f, dropout: %t (%v), batch size: %v, output: %s\n", model.Activations, alpha, config.Dropout, config.DropoutPercent, batch_size, output)
+	printf("Optimizer: %s, learning rate schedule: %s, features: %s\n", optimizer.State().Name, schedule.Name, extractor.State().Name)
+	printf("Input matrix: %vx%v  Output matrix: %vx%v\n", rx, cx, ry, cy)
+	if x_val != nil {
+		r_val, _ := x_val.Dims()
+		printf("Validation examples: %v, patience: %v epochs\n", r_val, config.Patience)
+	}
 
-		//Get error of layer_2
-		layer_2_error := new(mat.Dense)
-		layer_2_error.Sub(y, layer_2)
+	//Check the error ten times along the training
+	check := config.Epochs / 10
+	if check == 0 {
+		check = 1
+	}
+	//Save the state of the training on the checkpoint file, to continue it from the epoch next
+	save := func(next int) error {
+		model.Optimizer = optimizer.State()
+		if config.Checkpoint == "" {
+			return nil
+		}
+		return SaveCheckpoint(config.Checkpoint, &Checkpoint{Model: model, Epoch: next, Seed: config.Seed, Best: best, Wait: wait, LastMeanError: last_mean_error})
+	}
 
-		if (ep%10000) == 0 && ep > 5000 {
-			//Check error
+	for ep := first; ep <= config.Epochs; ep++ {
+		model.Metrics.Epochs = ep
+		//With validation examples they decide when to stop, instead of the training error
+		if x_val == nil && (ep%check) == 0 && ep > check/2 {
+			//Check error over the whole training data
+			output_error := new(mat.Dense)
+			output_error.Sub(y, model.forward(x))
 			var mean_err float64
-			mean_err = mat_mean(absolute(layer_2_error))
+			mean_err = mat_mean(absolute(output_error))
 			//If error is decreasing all GOOD, continue
 			if mean_err < last_mean_error {
-				fmt.Printf("delta after %v, iterations: %f\n", ep, mean_err)
+				printf("delta after %v, iterations: %f\n", ep, mean_err)
 				last_mean_error = mean_err
 			} else {
 				//If error is getting bigger then something is wrong, stop the process
-				fmt.Printf("break, delta: %f > last_mean_error: %f\n", mean_err, last_mean_error)
+				printf("break, delta: %f > last_mean_error: %f\n", mean_err, last_mean_error)
 				break
 			}
 		}
-		//Backward propagation layer 2, derivative and error
-		layer_2_delta := new(mat.Dense)
-		layer_2_delta.MulElem(layer_2_error, sig_to_dev(layer_2))
-
-		//Backward propagation layer 1, derivative and error
-		layer_1_error := new(mat.Dense)
-		layer_1_error.Product(layer_2_delta, synapse_1.T())
-
-		//Get delta of layer_1 from error
-		layer_1_delta := new(mat.Dense)
-		layer_1_delta.MulElem(layer_1_error, sig_to_dev(layer_1))
-
-		//Get the updated weights based on gradient decent
-		synapse_1_weight_update := new(mat.Dense)
-		synapse_0_weight_update := new(mat.Dense)
-		synapse_1_weight_update.Product(layer_1.T(), layer_2_delta)
-		synapse_0_weight_update.Product(layer_0.T(), layer_1_delta)
-
-		//Apply the learning rate alpha to synapse 1 matrix
-		mul := new(mat.Dense)
-		mul.Apply(func(i, j int, v float64) float64 { return alpha * v }, synapse_1_weight_update)
-		synapse_1.Add(synapse_1, mul)
-
-		//Aply learning rate alpha to synapse 0 matrix
-		mul1 := new(mat.Dense)
-		mul1.Apply(func(i, j int, v float64) float64 { return alpha * v }, synapse_0_weight_update)
-		synapse_0.Add(synapse_0, mul1)
-
-	}
-	//Store the synapse matrixes values on data
-	data := synapse{
-		Synapse_0:  synapse_0.RawMatrix(),
-		Synapse_1:  synapse_1.RawMatrix(),
-		Words:      words_db,
-		Categories: categories,
-	}
-	//Encode the data into a json
-	file, err := json.Marshal(data)
-	if err != nil {
-		fmt.Println(err)
-	}
-	//Save the file into model.json
-	_ = ioutil.WriteFile("model.json", file, 0644)
-}
-
-func Classify(sentence string, details bool, synapse_0 *mat.Dense, synapse_1 *mat.Dense, words []string, categories []string) Entries {
-	var result *mat.Dense
-	//Get the prediction of the ANN, and save it
-	result = think(sentence, details, synapse_0, synapse_1, words)
-	//Get the columns of result
-	_, c := result.Dims()
-	prediction := make(map[int]float64)
-	//Iterate thorugh all categories and identify the ones greater than ERROR_THRESHOLD
-	for i := 0; i < c; i++ {
-		if result.At(0, i) > ERROR_THRESHOLD {
-			//Save the ones that have a high certainity value
-			prediction[i] = result.At(0, i)
+		rate := schedule.Rate(alpha, ep)
+		//Every epoch has its own random numbers from the seed, so a training resumed from
+		//a checkpoint shuffles and drops the same neurons as if it never stopped
+		epoch_rng := rand.New(rand.NewSource(config.Seed + int64(ep)))
+		//Shuffle the rows on every epoch, so each batch sees different examples
+		order := epoch_rng.Perm(rx)
+		for start := 0; start < rx; start += batch_size {
+			end := start + batch_size
+			if end > rx {
+				end = rx
+			}
+			//Set input of the batch, layer 0, and its expected output
+			target := selectRows(y, order[start:end])
+			layers := []*mat.Dense{selectRows(x, order[start:end])}
+			//Activations of every layer before the dropout, and the dropout masks of the hidden layers
+			activated := []*mat.Dense{layers[0]}
+			masks := make([]*mat.Dense, len(weights))
+			//Feed forward through every layer, keeping the activations for backpropagation
+			for i, w := range weights {
+				prod := new(mat.Dense)
+				prod.Product(layers[i], w)
+				layer := activate(prod, model.Activations[i])
+				activated = append(activated, layer)
+
+				//Drop random neurons of the hidden layers, the next layer only sees the ones kept
+				if config.Dropout && i < len(weights)-1 {
+					r, c := layer.Dims()
+					masks[i+1] = dropoutMask(r, c, config.DropoutPercent, epoch_rng)
+					dropped := new(mat.Dense)
+					dropped.MulElem(layer, masks[i+1])
+					layer = dropped
+				}
+				layers = append(layers, layer)
+			}
+			last := len(layers) - 1
+
+			//Get error of the output layer
+			layer_error := new(mat.Dense)
+			layer_error.Sub(layers[last], target)
+
+			//Backward propagation of the output layer, derivative and error
+			//With cross-entropy loss the softmax derivative cancels out, the delta is the error itself
+			delta := layer_error
+			if output != SOFTMAX {
+				delta = new(mat.Dense)
+				delta.MulElem(layer_error, derivative(layers[last], output))
+			}
+			//Go backwards through every layer
+			gradients := make([]*mat.Dense, len(weights))
+			for i := len(weights) - 1; i >= 0; i-- {
+				//Get the gradient of the weights matrix
+				gradients[i] = new(mat.Dense)
+				gradients[i].Product(layers[i].T(), delta)
+				if i > 0 {
+					//Backward propagation to the previous layer, derivative and error
+					prev_error := new(mat.Dense)
+					prev_error.Product(delta, weights[i].T())
+					//Get delta of the previous layer from error
+					delta = new(mat.Dense)
+					delta.MulElem(prev_error, derivative(activated[i], model.Activations[i-1]))
+					//The neurons dropped on the forward pass get no error back
+					if masks[i] != nil {
+						delta.MulElem(delta, masks[i])
+					}
+				}
+			}
+			//Let the optimizer update the weights, with the learning rate of this epoch
+			optimizer.Update(weights, gradients, rate)
+		}
+		if x_val != nil {
+			//Check loss and accuracy on the validation examples
+			val_output := model.forward(x_val)
+			val_loss := loss(val_output, y_val, output)
+			val_accuracy := accuracy(val_output, y_val)
+			if ep%check == 0 {
+				printf("epoch %v, validation loss: %f, accuracy: %f\n", ep, val_loss, val_accuracy)
+			}
+			//Keep the weights with the best accuracy, or the same accuracy with lower loss
+			metrics := &model.Metrics
+			if best == nil || val_accuracy > metrics.ValidationAccuracy ||
+				(val_accuracy == metrics.ValidationAccuracy && val_loss < metrics.ValidationLoss) {
+				best = copyAll(weights)
+				metrics.BestEpoch, metrics.ValidationLoss, metrics.ValidationAccuracy = ep, val_loss, val_accuracy
+				wait = 0
+			} else if wait++; config.Patience > 0 && wait >= config.Patience {
+				//The model stopped getting better on the validation examples, it is only overfitting
+				printf("stop after %v epochs without improving validation\n", wait)
+				break
+			}
+		}
+		if config.CheckpointEvery > 0 && (ep+1)%config.CheckpointEvery == 0 {
+			if err := save(ep + 1); err != nil {
+				return nil, err
+			}
+		}
+		//When asked to stop, save a last checkpoint and leave
+		select {
+		case <-config.Stop:
+			if err := save(ep + 1); err != nil {
+				return nil, err
+			}
+			printf("training interrupted after epoch %v\n", ep)
+			return model, ErrInterrupted
+		default:
 		}
 	}
-	var es Entries
-	//Iterate through the map to identify the value and the index of the prediciton
-	for k, v := range prediction {
-		//Get the corresponding category from the categoies array
-		es = append(es, Entry{Val: v, Key: categories[k]})
-	}
-	if es != nil {
-		fmt.Printf("Input: %s\n Category: %v Confidence: %v\n", sentence, es[0].Key, es[0].Val)
-	} else {
-		es = append(es, Entry{Val: 99.99, Key: categories[10]})
-		fmt.Printf("Input: %s\n Category: %v Confidence: %v\n", sentence, es[0].Key, es[0].Val)
+	if best != nil {
+		//Restore the weights of the best epoch
+		for i := range weights {
+			weights[i].Copy(best[i])
+		}
+		printf("best epoch %v, validation loss: %f, accuracy: %f\n", model.Metrics.BestEpoch, model.Metrics.ValidationLoss, model.Metrics.ValidationAccuracy)
 	}
-	//Get the response based on the identified category
-	answer := response(es)
-	fmt.Printf("Output: %v\n", answer[0].Key)
-	return answer
+	//Keep the optimizer state, so the training can continue from this model
+	model.Optimizer = optimizer.State()
+	//Keep the hyperparameters of the training with the model
+	hyperparameters := config
+	hyperparameters.Resume, hyperparameters.Stop = nil, nil
+	model.Config, model.Created = &hyperparameters, time.Now().UTC()
+	model.MaxDistance, model.Threshold = config.MaxDistance, config.Threshold
+	return model, nil
 }
 
-func think(sentence string, details bool, synapse_0 *mat.Dense, synapse_1 *mat.Dense, words []string) *mat.Dense {
+func think(sentence string, details bool, model *Model) (*mat.Dense, []Correction) {
 	//Given a sentence, get the binary vector according to the words used, and the word on the data base
-	x := bow(sentence, words, details)
+	x, corrections := bow(sentence, model, details)
 	if details {
 		fmt.Println("sentence:", sentence, "\nbow:", x)
 	}
-	//Input the binarized sentence as fisrt Layer
-	l0 := x
-	//Matrix multiplication Intput and Hidden layer
-	d := new(mat.Dense)
-	d.Product(l0, synapse_0)
-	l1 := sigmoid(d)
-	d1 := new(mat.Dense)
-	d1.Product(l1, synapse_1)
-	//Output layer, response of the newtwork
-	l2 := sigmoid(d1)
-	return l2
+	//Input the binarized sentence as fisrt Layer, and get the output layer, response of the newtwork
+	return model.forward(x), corrections
 }
 
-//This function is going to set the split rule, to ")"
-func PhraseSplitFunc(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	//If we are at the end of file and there no  data
-	if atEOF && len(data) == 0 {
-		//Then we finished
-		return 0, nil, nil
-	}
-	//We search for ")" on the data, if it is after the first character of data
-	if i := strings.Index(string(data), ")"); i >= 0 {
-		//Then we get all the characters before ") and if this is not empty"
-		if string(data[0:i]) != "" {
-			//We then move on after the ")" and return all the data before ")"
-			return i + 1, data[0 : i+1], nil
-		}
+//This function copies the rows of m given by index, in that order, to a new matrix
+func selectRows(m *mat.Dense, index []int) *mat.Dense {
+	_, c := m.Dims()
+	output := mat.NewDense(len(index), c, nil)
+	for i, row := range index {
+		output.SetRow(i, m.RawRowView(row))
 	}
-	//If we are at end of file
-	if atEOF {
-		//We return the remain data, and the index after this data
-		return len(data), data, nil
-	}
-
-	return
-}
-
-//This function split our file in lines of sentences,category
-func ScanPhrases(path string) ([]string, error) {
-	//We open the database file
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	//No matter what we close it
-	defer file.Close()
-	//We start a new sacnner of our database file
-	scanner := bufio.NewScanner(file)
-	//We split our file in lines according to the custom PhraseSplitFunction
-	scanner.Split(PhraseSplitFunc)
-
-	var phrase []string
-	//We scan all inside the file
-	for scanner.Scan() {
-		//Store each phrase and category inside a slice
-		phrase = append(phrase, scanner.Text())
-	}
-	return phrase, err
+	return output
 }
 
-//This function is going get every word remove accents and remove articles and unnecesary words
-func scanWords(list string) []string {
+//This function is going get every word and go through the steps of the preprocessing with them,
+//by default lowercase them, remove articles and unnecesary words, and remove accents
+func scanWords(list string, steps pipeline) []string {
 	//We start a new scanner
 	scanner := bufio.NewScanner(strings.NewReader(list))
 	//We split according to spaces
 	scanner.Split(bufio.ScanWords)
-	var boolean bool
 	var words []string
-	ign := []string{"la", "a", "un", "una", "?", "!", "el", "con", "sin", "en", "para",
-		"por", ".", "siempre", "desde", "los", "las", "me", "que", "tan", "de", "favor"}
 	//We scan for every word
 	for scanner.Scan() {
-		text := scanner.Text()
-		//We find if the word is inside ignored database
-		boolean, _ = Find(ign, strings.ToLower(text))
-		if boolean {
-			//If true then we ignore it
-			continue
-		} else {
-			//If it is not, then we remove accents
-			t := transform.Chain(norm.NFD, transform.RemoveFunc(isMn), norm.NFC)
-			result, _, _ := transform.String(t, text)
-			//Save it on the word data base
-			words = append(words, strings.ToLower(result))
-		}
+		words = append(words, scanner.Text())
 	}
-
-	return words
+	//Every step of the preprocessing changes the words, in order
+	return steps.words(words)
 }
 
 //This function set our data base correcly, on a map in the way category:sentences
 //And get a word_database of al unique words of all sentences
-//And a category database of all categories in the database
-func SetDb(line []string) (map[string][]string, []string, []string) {
+//And a category database of all categories in the database.
+//The words are the ones the preprocessing gets from the sentences
+func SetDb(examples []Example, preprocessing Preprocessing) (map[string][]string, []string, []string) {
 	words := []string{}
 	categories := []string{}
 	keys := []string{}
-	var id, text string
 	var boolean bool
-	//Set the REGEX rules for category
-	re1 := regexp.MustCompile(`\((.+)\)`)
-	//Set the regex rule for sentence
-	re2 := regexp.MustCompile(`\#(.+)\s\(`)
+	steps := preprocessing.build()
 	//Initialize database map
 	db := make(map[string][]string)
-	//Iterate through every word in the line
-	for _, sentence := range line {
-		//Identify the category
-		id = string(re1.FindAllSubmatch([]byte(sentence), -1)[0][1])
-		if id != "noanswer" {
-			//Identify the sentence
-			text = string(re2.FindAllSubmatch([]byte(sentence), -1)[0][1])
-			//Save the category and sentence
-			db[id] = append(db[id], text)
-			//If the category is "noanswer" just insert an "" as sentence
-		} else {
-			db[id] = append(db[id], "")
+	//Iterate through every example of the database
+	for _, example := range examples {
+		//If the category is "noanswer" just insert an "" as sentence
+		if example.Category == "noanswer" {
+			db[example.Category] = append(db[example.Category], "")
+			continue
 		}
+		//Save the category and sentence
+		db[example.Category] = append(db[example.Category], example.Text)
 		//Get an array of every word on the sentence
-		w := scanWords(text)
+		w := scanWords(example.Text, steps)
 		//Iterate through all words of the sentence
 		for _, wrd := range w {
 			//Find if wrd is already in words
@@ -362,6 +520,29 @@ func SetDb(line []string) (map[string][]string, []string, []string) {
 	return db, words, categories
 }
 
+//This function gets the word database and the category database of a database map,
+//like SetDb does from the lines, with the categories in alphabetical order
+func Vocabulary(db map[string][]string, preprocessing Preprocessing) ([]string, []string) {
+	words := []string{}
+	categories := []string{}
+	for k := range db {
+		categories = append(categories, k)
+	}
+	sort.Strings(categories)
+	steps := preprocessing.build()
+	for _, k := range categories {
+		for _, sentence := range db[k] {
+			for _, wrd := range scanWords(sentence, steps) {
+				//If wrd not in words then add it
+				if boolean, _ := Find(words, wrd); !boolean {
+					words = append(words, wrd)
+				}
+			}
+		}
+	}
+	return words, categories
+}
+
 //This function finds if a string is already present on a slice
 func Find(slice []string, val string) (bool, int) {
 	//Iterate throug index of a slice
@@ -374,8 +555,16 @@ func Find(slice []string, val string) (bool, int) {
 	return false, 0
 }
 
-//This function change our sentences database into a binary array of sentences
-func Binarize(db map[string][]string, word_db []string, categories []string) (*mat.Dense, *mat.Dense) {
+//This function change our sentences database into a binary array of sentences,
+//with the words the preprocessing gets from them
+func Binarize(db map[string][]string, word_db []string, categories []string, preprocessing Preprocessing) (*mat.Dense, *mat.Dense) {
+	counts, output := CountWords(db, word_db, categories, preprocessing)
+	return binaryFeatures{}.Transform(counts), output
+}
+
+//This function change our sentences database into an array of how many times every word is on every sentence,
+//with the words the preprocessing gets from them. The training turns it into the features of the network
+func CountWords(db map[string][]string, word_db []string, categories []string, preprocessing Preprocessing) (*mat.Dense, *mat.Dense) {
 
 	count := 0
 	keys := []string{}
@@ -392,21 +581,22 @@ func Binarize(db map[string][]string, word_db []string, categories []string) (*m
 	output := mat.NewDense(count, len(categories), nil)
 
 	i := 0
+	steps := preprocessing.build()
 	//Iteration through every category
 	for _, k := range keys {
 		pattern_words := []string{}
 		//Iteration through every sentence
 		for _, sentence := range db[k] {
 			//Get all words in the sentence
-			pattern_words = scanWords(sentence)
+			pattern_words = scanWords(sentence, steps)
 			//For every word in slice of words
 			for _, w := range pattern_words {
 				//For every word in word database
 				for j, item := range word_db {
 					//If both are the same
 					if item == w {
-						//Assign a one
-						training.Set(i, j, 1)
+						//Count one more
+						training.Set(i, j, training.At(i, j)+1)
 					}
 				}
 			}
@@ -425,59 +615,23 @@ func Binarize(db map[string][]string, word_db []string, categories []string) (*m
 	return training, output
 }
 
-func response(category Entries) Entries {
+func response(category Entries, intents_db map[string][]string, rng *rand.Rand) (Entries, error) {
 	var sentence string
-	var v int
-	//Load intents from file
-	intents_db := LoadIntens("C:\\Users\\jrtor\\go\\src\\text_neural_network\\intents.json")
-	//Search for the correct category
-	switch category[0].Key {
-	case "greeting":
-		//Generate a random number
-		v = rand.Intn(len(intents_db.Category.Greeting))
-		//Choose a phrase of index v from array
-		sentence = intents_db.Category.Greeting[v]
-	case "goodbye":
-		v = rand.Intn(len(intents_db.Category.Goodbye))
-		sentence = intents_db.Category.Goodbye[v]
-	case "thanks":
-		v = rand.Intn(len(intents_db.Category.Thanks))
-		sentence = intents_db.Category.Thanks[v]
-	case "noanswer":
-		v = rand.Intn(len(intents_db.Category.Noanswer))
-		sentence = intents_db.Category.Noanswer[v]
-	case "options":
-		v = rand.Intn(len(intents_db.Category.Options))
-		sentence = intents_db.Category.Options[v]
-	case "food,order,pizza":
-		v = rand.Intn(len(intents_db.Category.Orderpizza))
-		sentence = intents_db.Category.Orderpizza[v]
-	case "food,order,hamburger":
-		v = rand.Intn(len(intents_db.Category.Orderham))
-		sentence = intents_db.Category.Orderham[v]
-	case "food,order,salad":
-		v = rand.Intn(len(intents_db.Category.Ordersalad))
-		sentence = intents_db.Category.Ordersalad[v]
-	case "drinks,order,water":
-		v = rand.Intn(len(intents_db.Category.Orderwater))
-		sentence = intents_db.Category.Orderwater[v]
-	case "drinks,order,tea":
-		v = rand.Intn(len(intents_db.Category.Ordertea))
-		sentence = intents_db.Category.Ordertea[v]
-	case "drinks,order,soda":
-		v = rand.Intn(len(intents_db.Category.Ordersoda))
-		sentence = intents_db.Category.Ordersoda[v]
-	case "disliked":
-		v = rand.Intn(len(intents_db.Category.Disliked))
-		sentence = intents_db.Category.Disliked[v]
-	case "liked":
-		v = rand.Intn(len(intents_db.Category.Liked))
-		sentence = intents_db.Category.Liked[v]
+	//Search for the responses of the identified category
+	answers, ok := intents_db[category[0].Key]
+	if !ok || len(answers) == 0 {
+		//If the category has no responses, answer as if we didn't understand
+		answers = intents_db["noanswer"]
+	}
+	if len(answers) == 0 {
+		return nil, fmt.Errorf("%w for category %s, and no noanswer responses", ErrNoResponse, category[0].Key)
 	}
+	//Choose a random phrase from the array
+	sentence = answers[rng.Intn(len(answers))]
 	//Save sentence inside es, with actual value of centainty
 	var es Entries
 	es = append(es, Entry{Val: category[0].Val, Key: sentence})
-	return es
+	return es, nil
 }
 
 //This function applies the function sigmoid elemnt wise to a matrix
@@ -491,6 +645,32 @@ func sigmoid(v *mat.Dense) *mat.Dense {
 	return output
 }
 
+//This function applies softmax to every row of a matrix, so each row sums to 1
+func softmax(v *mat.Dense) *mat.Dense {
+	//Get matrix dimension
+	r, c := v.Dims()
+	//Initialize matrix output
+	output := mat.NewDense(r, c, nil)
+	for i := 0; i < r; i++ {
+		row := v.RawRowView(i)
+		//Subtract the max value of the row to avoid overflows on math.Exp
+		max := row[0]
+		for _, val := range row {
+			max = math.Max(max, val)
+		}
+		var sum float64
+		for j, val := range row {
+			e := math.Exp(val - max)
+			output.Set(i, j, e)
+			sum += e
+		}
+		for j := 0; j < c; j++ {
+			output.Set(i, j, output.At(i, j)/sum)
+		}
+	}
+	return output
+}
+
 //This function applies the second derivate of sigmoid function to matrix v
 func sig_to_dev(v *mat.Dense) *mat.Dense {
 	//Initialize matrix m
@@ -504,29 +684,34 @@ func sig_to_dev(v *mat.Dense) *mat.Dense {
 	return output
 }
 
-//This function binarize the input sentence to be able to insert it to the NN
-func bow(sentence string, words_db []string, details bool) *mat.Dense {
+//This function counts the words of the input sentence and gets their features to be able to insert it to the NN,
+//the words that are not on the words database are read as the closest one when the model allows typos
+func bow(sentence string, model *Model, details bool) (*mat.Dense, []Correction) {
 	//Get every word of the sentence
-	sentence_words := scanWords(sentence)
+	sentence_words := scanWords(sentence, model.pipeline())
 	//Initialize slice of float64
-	bag := make([]float64, len(words_db))
+	bag := make([]float64, len(model.Words))
+	var corrections []Correction
 	//Iterate through every word of the sentence
 	for _, word := range sentence_words {
-		//Iterate through every word in words database
-		for i, item := range words_db {
-			//If they are equal
-			if item == word {
-				//Set a one
-				bag[i] = 1
-			}
+		//Find the word in words database
+		if found, i := Find(model.Words, word); found {
+			//Count one more
+			bag[i]++
+			continue
+		}
+		//If it is not there it may be a typo of a word that is
+		if c, ok := model.correct(word); ok {
+			bag[c.Index]++
+			corrections = append(corrections, c)
 		}
 	}
 	if details {
 		fmt.Println(bag)
 	}
-	//Create new vector from slice
-	v := mat.NewDense(1, len(bag), bag)
-	return v
+	//Create new vector from slice, with the features the model was trained with
+	v := model.extractor().Transform(mat.NewDense(1, len(bag), bag))
+	return v, corrections
 }
 
 //This function gets the absolute value of a matrix
@@ -555,9 +740,63 @@ func mat_mean(m1 *mat.Dense) float64 {
 	return mean
 }
 
+//TrainConfig holds the hyperparameters of a training run
+type TrainConfig struct {
+	//Hidden layers, from the input to the output layer
+	Layers []Layer
+	Alpha  float64
+	Epochs int
+	//Drop each neuron of the hidden layers with probability DropoutPercent, only while training
+	Dropout        bool
+	DropoutPercent float64
+	//Rows used on every gradient step, 0 or less uses the whole matrix at once
+	BatchSize int
+	//Use a softmax output with cross-entropy loss instead of sigmoid with squared error
+	Softmax bool
+	//Fraction of the examples of every category held out to validate the model, 0 to train with all of them
+	Validation float64
+	//Stop after this many epochs without improving the validation accuracy, 0 to never stop early
+	Patience int
+	//One of sgd, momentum, rmsprop or adam, sgd if empty
+	Optimizer string
+	//How the learning rate changes from Alpha along the epochs
+	Schedule Schedule
+	//Don't print the progress of the training
+	Quiet bool
+	//Seed of every random number of the training: weights, validation split, shuffling and dropout
+	Seed int64
+	//The row of x every variant made by augment was made from, -1 for the other rows. The variants are
+	//never held out for validation, and they are not trained with when the row they were made from is
+	Sources []int `json:"-"`
+	//Edits a word not on the vocabulary can be from one that is to be read as it, saved with the model
+	MaxDistance int
+	//Score the best category needs for a sentence to be understood, saved with the model. ERROR_THRESHOLD if it is 0
+	Threshold float64 `json:",omitempty"`
+	//How the sentences were turned into the words of x, saved with the model. The default steps if it has none
+	Preprocessing Preprocessing `json:",omitempty"`
+	//Features the network takes from the counts of the words on x: binary, tf or tfidf, binary if empty
+	Features string
+	//File to save checkpoints on every CheckpointEvery epochs, and when Stop is closed
+	Checkpoint      string
+	CheckpointEvery int
+	Stop            <-chan struct{} `json:"-"`
+	//Checkpoint to continue training from, instead of starting with random weights
+	Resume *Checkpoint `json:"-"`
+}
+
+//This function gets the preprocessing of the training, the default one if it has no steps
+func (config TrainConfig) preprocessing() Preprocessing {
+	if len(config.Preprocessing.Steps) == 0 {
+		return DefaultPreprocessing()
+	}
+	return config.Preprocessing
+}
+
 type Entry struct {
 	Val float64
 	Key string
+	//Words of the sentence that are not on the vocabulary and were read as the closest word that is
+	Corrections []Correction `json:",omitempty"`
 }
 
 type Entries []Entry
@@ -571,28 +810,29 @@ func isMn(r rune) bool {
 }
 
 type synapse struct {
-	Synapse_0  blas64.General
-	Synapse_1  blas64.General
-	Words      []string
-	Categories []string
+	Synapses    []blas64.General
+	Activations []string
+	Words       []string
+	Categories  []string
+	Alpha       float64
+	Schedule    Schedule
+	Optimizer   OptimizerState
+	Metrics     TrainMetrics
+
+	Created       time.Time
+	DatasetSHA256 string       `json:",omitempty"`
+	Config        *TrainConfig `json:",omitempty"`
+	Preprocessing Preprocessing
+	Features      Features
+	MaxDistance   int     `json:",omitempty"`
+	Threshold     float64 `json:",omitempty"`
+	//Models saved with the two fixed layers, before any hidden layer could be defined
+	Synapse_0 *blas64.General `json:",omitempty"`
+	Synapse_1 *blas64.General `json:",omitempty"`
+	Output    string          `json:",omitempty"`
 }
 
+//Outmost is the layout of intents.json, responses grouped by category name
 type Outmost struct {
-	Category Inner
-}
-
-type Inner struct {
-	Greeting   []string
-	Goodbye    []string
-	Thanks     []string
-	Noanswer   []string
-	Options    []string
-	Orderpizza []string
-	Orderham   []string
-	Ordersalad []string
-	Orderwater []string
-	Ordertea   []string
-	Ordersoda  []string
-	Disliked   []string
-	Liked      []string
+	Category map[string][]string `json:"category"`
 }
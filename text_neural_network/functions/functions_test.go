@@ -0,0 +1,206 @@
+package functions
+
+import (
+	"math"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//Sentences of three categories that share no words, any network should learn them
+var toyExamples = []Example{
+	{Text: "hola amigo", Category: "greeting"},
+	{Text: "hola buenos dias", Category: "greeting"},
+	{Text: "buenos dias amigo", Category: "greeting"},
+	{Text: "hola que tal", Category: "greeting"},
+	{Text: "adios amigo mio", Category: "goodbye"},
+	{Text: "hasta luego", Category: "goodbye"},
+	{Text: "nos vemos luego", Category: "goodbye"},
+	{Text: "adios hasta pronto", Category: "goodbye"},
+	{Text: "quiero pizza", Category: "food"},
+	{Text: "tengo hambre", Category: "food"},
+	{Text: "quiero comer pizza", Category: "food"},
+	{Text: "comer algo rico", Category: "food"},
+}
+
+//This function gets the database, the vocabulary and the matrixes of the toy examples, like the train command does
+func toyData() (map[string][]string, *mat.Dense, *mat.Dense, []string, []string) {
+	db, words, categories := SetDb(toyExamples, DefaultPreprocessing())
+	x, y := CountWords(db, words, categories, DefaultPreprocessing())
+	return db, x, y, words, categories
+}
+
+//This function gets a small and quiet training configuration for the toy examples
+func toyConfig() TrainConfig {
+	return TrainConfig{Layers: []Layer{{Size: 8, Activation: SIGMOID}}, Alpha: 0.5, Epochs: 400, Quiet: true, Seed: 1}
+}
+
+//This function checks a model gets the category of every sentence of a database right
+func checkLearned(t *testing.T, model *Model, db map[string][]string) {
+	t.Helper()
+	for category, sentences := range db {
+		for _, sentence := range sentences {
+			if got := model.Predict(sentence, false)[0].Key; got != category {
+				t.Errorf("%q is %s, want %s", sentence, got, category)
+			}
+		}
+	}
+}
+
+func TestLoadIntens(t *testing.T) {
+	tests := []struct {
+		content string
+		want    map[string][]string
+	}{
+		{`{"category": {"greeting": ["Hola!", "Buenas"], "food,order,pizza": ["Marchando"]}}`,
+			map[string][]string{"greeting": {"Hola!", "Buenas"}, "food,order,pizza": {"Marchando"}}},
+		//A new category is only a new key of the file
+		{`{"category": {"greeting": ["Hola!"], "weather": ["Hace sol"]}}`,
+			map[string][]string{"greeting": {"Hola!"}, "weather": {"Hace sol"}}},
+		{`{"category": {}}`, map[string][]string{}},
+	}
+	for _, test := range tests {
+		got, err := LoadIntens(writeTestFile(t, t.TempDir(), "intents.json", test.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("LoadIntens(%s) = %v, want %v", test.content, got, test.want)
+		}
+	}
+}
+
+func TestIntentsCategories(t *testing.T) {
+	//The responses are keyed by the same names SetDb finds on the database
+	examples, err := ScanPhrases(filepath.Join("..", "chatss.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _, categories := SetDb(examples, DefaultPreprocessing())
+	intents, err := LoadIntens(filepath.Join("..", "intents.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for category, responses := range intents {
+		if len(responses) == 0 {
+			t.Errorf("category %s has no responses", category)
+		}
+		keys = append(keys, category)
+	}
+	sort.Strings(keys)
+	if !reflect.DeepEqual(keys, categories) {
+		t.Errorf("intents.json has the categories %q, the database %q", keys, categories)
+	}
+}
+
+func TestSelectRows(t *testing.T) {
+	m := mat.NewDense(3, 2, []float64{1, 2, 3, 4, 5, 6})
+	tests := []struct {
+		index []int
+		want  []float64
+	}{
+		{[]int{0, 1, 2}, []float64{1, 2, 3, 4, 5, 6}},
+		{[]int{2, 0}, []float64{5, 6, 1, 2}},
+		{[]int{1, 1}, []float64{3, 4, 3, 4}},
+	}
+	for _, test := range tests {
+		got := selectRows(m, test.index)
+		if !mat.Equal(got, mat.NewDense(len(test.index), 2, test.want)) {
+			t.Errorf("selectRows(%v) = %v, want %v", test.index, mat.Formatted(got), test.want)
+		}
+	}
+}
+
+func TestTrainBatchSizes(t *testing.T) {
+	db, x, y, words, categories := toyData()
+	rows, _ := x.Dims()
+	tests := []struct {
+		name  string
+		batch int
+	}{
+		{"one example", 1},
+		{"uneven batches", 5},
+		{"whole data", rows},
+		//A batch bigger than the data, or none, is full-batch gradient descent
+		{"bigger than the data", rows + 10},
+		{"negative", -1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := toyConfig()
+			config.BatchSize = test.batch
+			model, err := Train(x, y, config, words, categories)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkLearned(t, model, db)
+		})
+	}
+}
+
+func TestSoftmax(t *testing.T) {
+	tests := []struct {
+		name string
+		row  []float64
+		want []float64
+	}{
+		{"equal values", []float64{2, 2}, []float64{0.5, 0.5}},
+		{"one twice the other", []float64{0, math.Log(2)}, []float64{1.0 / 3, 2.0 / 3}},
+		//Without subtracting the max of the row math.Exp gives +Inf
+		{"big values", []float64{1000, 1000 + math.Log(3)}, []float64{0.25, 0.75}},
+		{"negative values", []float64{-1000, -1000}, []float64{0.5, 0.5}},
+		{"single category", []float64{7}, []float64{1}},
+	}
+	for _, test := range tests {
+		got := softmax(mat.NewDense(1, len(test.row), test.row)).RawRowView(0)
+		for j := range test.want {
+			if math.Abs(got[j]-test.want[j]) > 1e-12 {
+				t.Errorf("%s: softmax(%v) = %v, want %v", test.name, test.row, got, test.want)
+				break
+			}
+		}
+	}
+}
+
+func TestTrainSoftmax(t *testing.T) {
+	db, x, y, words, categories := toyData()
+	config := toyConfig()
+	config.Softmax = true
+	config.Alpha = 0.1
+	model, err := Train(x, y, config, words, categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := model.Activations[len(model.Activations)-1]; last != SOFTMAX {
+		t.Fatalf("output activation is %s, want %s", last, SOFTMAX)
+	}
+	checkLearned(t, model, db)
+	//The scores of a softmax model are a probability distribution over the categories
+	for _, sentence := range []string{"hola amigo", "quiero pizza", "palabras que no conoce"} {
+		var sum float64
+		for _, entry := range model.Predict(sentence, false) {
+			sum += entry.Val
+		}
+		if math.Abs(sum-1) > 1e-9 {
+			t.Errorf("the scores of %q sum %v, want 1", sentence, sum)
+		}
+	}
+}
+
+func TestVocabulary(t *testing.T) {
+	//The database map has the same words and categories SetDb found on the lines
+	db, _, _, words, categories := toyData()
+	got_words, got_categories := Vocabulary(db, DefaultPreprocessing())
+	if !reflect.DeepEqual(got_categories, categories) {
+		t.Errorf("Vocabulary categories = %v, want %v", got_categories, categories)
+	}
+	sort.Strings(words)
+	sort.Strings(got_words)
+	if !reflect.DeepEqual(got_words, words) {
+		t.Errorf("Vocabulary words = %v, want %v", got_words, words)
+	}
+}
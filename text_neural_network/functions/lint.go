@@ -0,0 +1,191 @@
+package functions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//Severities of the problems the linter finds. Errors break the bot, warnings only make it worse
+const (
+	LINT_ERROR   = "error"
+	LINT_WARNING = "warning"
+)
+
+//Issue is a problem of a dataset, on a line of a file when it has one
+type Issue struct {
+	Severity string
+	File     string
+	Line     int `json:",omitempty"`
+	Category string
+	Msg      string
+}
+
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return fmt.Sprintf("%s: %s: %s", i.File, i.Severity, i.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s: %s", i.File, i.Line, i.Severity, i.Msg)
+}
+
+//LintOptions are the limits of the linter
+type LintOptions struct {
+	//Categories with fewer examples are warned about
+	MinExamples int
+	//Categories with this many times fewer examples than the largest one are warned about
+	Imbalance float64
+	//Sentences this many edits or fewer apart are near duplicates
+	NearDistance int
+	//How the sentences are turned into words to find the ones that appear only once,
+	//the same as the training. The default steps if it has none
+	Preprocessing Preprocessing
+}
+
+//This function gets the default limits of the linter
+func DefaultLintOptions() LintOptions {
+	return LintOptions{MinExamples: 3, Imbalance: 4, NearDistance: 2, Preprocessing: DefaultPreprocessing()}
+}
+
+//This function checks a dataset and the responses of its categories, the ones of intents.json.
+//Errors are exact duplicates, the same sentence on two categories, and categories without responses
+//or responses without a category. Warnings are near duplicates, categories with few examples,
+//and words that appear only once, that the network can't learn anything from.
+//The generated examples are left out, they are near duplicates on purpose
+func Lint(file string, dataset *Dataset, intents_file string, intents map[string][]string, options LintOptions) []Issue {
+	originals, _ := SplitGenerated(dataset.Examples)
+	dataset = &Dataset{Examples: originals, Responses: dataset.Responses}
+	var issues []Issue
+	add := func(severity string, line int, category string, format string, args ...interface{}) {
+		issues = append(issues, Issue{Severity: severity, File: file, Line: line, Category: category, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	//Compare the sentences lowercase, with no accents and single spaces
+	type seen struct {
+		Example
+		key string
+	}
+	var sentences []seen
+	first := make(map[string]seen)
+	counts := make(map[string]int)
+	for _, example := range dataset.Examples {
+		counts[example.Category]++
+		if example.Category == "noanswer" {
+			continue
+		}
+		current := seen{Example: example, key: lintKey(example.Text)}
+		if original, ok := first[current.key]; ok {
+			if original.Category == example.Category {
+				add(LINT_ERROR, example.Line, example.Category, "duplicate of line %d: %q", original.Line, example.Text)
+			} else {
+				add(LINT_ERROR, example.Line, example.Category, "%q is %s here and %s on line %d", example.Text, example.Category, original.Category, original.Line)
+			}
+			continue
+		}
+		first[current.key] = current
+		sentences = append(sentences, current)
+	}
+
+	//Sentences that only differ on a couple of letters are probably the same one
+	for i, a := range sentences {
+		for _, b := range sentences[:i] {
+			//Short sentences are always a few letters apart
+			if len([]rune(a.key)) <= 2*options.NearDistance || len([]rune(b.key)) <= 2*options.NearDistance {
+				continue
+			}
+			if d := editDistance(a.key, b.key); d <= options.NearDistance {
+				if a.Category == b.Category {
+					add(LINT_WARNING, a.Line, a.Category, "%q is %d edits from %q on line %d", a.Text, d, b.Text, b.Line)
+				} else {
+					add(LINT_WARNING, a.Line, a.Category, "%q (%s) is %d edits from %q (%s) on line %d", a.Text, a.Category, d, b.Text, b.Category, b.Line)
+				}
+			}
+		}
+	}
+
+	//Categories with too few examples, or far fewer than the largest one
+	var categories []string
+	largest := 0
+	for category, n := range counts {
+		categories = append(categories, category)
+		if n > largest {
+			largest = n
+		}
+	}
+	sort.Strings(categories)
+	for _, category := range categories {
+		n := counts[category]
+		switch {
+		case category == "noanswer":
+		case n < options.MinExamples:
+			add(LINT_WARNING, 0, category, "category %s has %d examples, it should have at least %d", category, n, options.MinExamples)
+		case options.Imbalance > 0 && float64(n)*options.Imbalance < float64(largest):
+			add(LINT_WARNING, 0, category, "category %s has %d examples, the largest category has %d", category, n, largest)
+		}
+	}
+
+	//Every category needs responses, and every response a category
+	if intents != nil {
+		for _, category := range categories {
+			if len(intents[category]) == 0 {
+				add(LINT_ERROR, 0, category, "category %s has no responses on %s", category, intents_file)
+			}
+		}
+		var names []string
+		for category := range intents {
+			names = append(names, category)
+		}
+		sort.Strings(names)
+		for _, category := range names {
+			if _, ok := counts[category]; !ok {
+				issues = append(issues, Issue{Severity: LINT_ERROR, File: intents_file, Category: category, Msg: fmt.Sprintf("category %s has responses but no examples on %s", category, file)})
+			}
+		}
+	}
+
+	//Words that only one sentence has
+	uses := make(map[string]int)
+	line := make(map[string]int)
+	category := make(map[string]string)
+	steps := TrainConfig{Preprocessing: options.Preprocessing}.preprocessing().build()
+	for _, example := range dataset.Examples {
+		for _, word := range scanWords(example.Text, steps) {
+			if uses[word] == 0 {
+				line[word], category[word] = example.Line, example.Category
+			}
+			uses[word]++
+		}
+	}
+	var words []string
+	for word, n := range uses {
+		if n == 1 {
+			words = append(words, word)
+		}
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if line[words[i]] != line[words[j]] {
+			return line[words[i]] < line[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	for _, word := range words {
+		add(LINT_WARNING, line[word], category[word], "word %q appears only once", word)
+	}
+
+	//Show the issues in the order of the file, the ones without a line at the end
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.File != b.File {
+			return a.File == file
+		}
+		if (a.Line == 0) != (b.Line == 0) {
+			return b.Line == 0
+		}
+		return a.Line < b.Line
+	})
+	return issues
+}
+
+//This function gets the form of a sentence the linter compares: lowercase, with no accents and single spaces
+func lintKey(text string) string {
+	return strings.Join(strings.Fields(foldAccents(strings.ToLower(text))), " ")
+}
@@ -0,0 +1,122 @@
+package functions
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	dataset := &Dataset{Examples: []Example{
+		{Text: "hola amigo", Category: "greeting", Line: 1},
+		{Text: "Hola  Amigo", Category: "greeting", Line: 2},
+		{Text: "buenos dias", Category: "greeting", Line: 3},
+		{Text: "buenos días", Category: "goodbye", Line: 4},
+		{Text: "quiero una pizza", Category: "food", Line: 5},
+		{Text: "quiero una pizzas", Category: "food", Line: 6},
+		{Text: "adios", Category: "goodbye", Line: 7},
+		{Text: "", Category: "noanswer", Line: 8},
+		//The generated examples are near duplicates on purpose
+		{Text: "ola amigo", Category: "greeting", Line: 9, Generated: true, Source: "hola amigo"},
+	}}
+	intents := map[string][]string{"greeting": {"Hola!"}, "food": {"Marchando"}, "thanks": {"De nada"}}
+	tests := []struct {
+		name    string
+		intents map[string][]string
+		want    []string
+	}{
+		{"with intents", intents, []string{
+			`chatss.txt:2: error: duplicate of line 1: "Hola  Amigo"`,
+			`chatss.txt:4: error: "buenos días" is goodbye here and greeting on line 3`,
+			`chatss.txt:5: warning: word "pizza" appears only once`,
+			`chatss.txt:6: warning: "quiero una pizzas" is 1 edits from "quiero una pizza" on line 5`,
+			`chatss.txt:6: warning: word "pizzas" appears only once`,
+			`chatss.txt:7: warning: word "adios" appears only once`,
+			`chatss.txt: warning: category food has 2 examples, it should have at least 3`,
+			`chatss.txt: warning: category goodbye has 2 examples, it should have at least 3`,
+			`chatss.txt: error: category goodbye has no responses on intents.json`,
+			`chatss.txt: error: category noanswer has no responses on intents.json`,
+			`intents.json: error: category thanks has responses but no examples on chatss.txt`,
+		}},
+		{"without intents", nil, []string{
+			`chatss.txt:2: error: duplicate of line 1: "Hola  Amigo"`,
+			`chatss.txt:4: error: "buenos días" is goodbye here and greeting on line 3`,
+			`chatss.txt:5: warning: word "pizza" appears only once`,
+			`chatss.txt:6: warning: "quiero una pizzas" is 1 edits from "quiero una pizza" on line 5`,
+			`chatss.txt:6: warning: word "pizzas" appears only once`,
+			`chatss.txt:7: warning: word "adios" appears only once`,
+			`chatss.txt: warning: category food has 2 examples, it should have at least 3`,
+			`chatss.txt: warning: category goodbye has 2 examples, it should have at least 3`,
+		}},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, issue := range Lint("chatss.txt", dataset, "intents.json", test.intents, DefaultLintOptions()) {
+			got = append(got, issue.String())
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: issues\n%q\nwant\n%q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestLintOptions(t *testing.T) {
+	//Eight examples of a and one of b
+	dataset := &Dataset{}
+	for i := 1; i <= 8; i++ {
+		dataset.Examples = append(dataset.Examples, Example{Text: fmt.Sprintf("hola numero %d", i), Category: "a", Line: i})
+	}
+	dataset.Examples = append(dataset.Examples, Example{Text: "adios", Category: "b", Line: 9})
+	tests := []struct {
+		options LintOptions
+		want    []string
+	}{
+		{DefaultLintOptions(), []string{"category b has 1 examples, it should have at least 3"}},
+		{LintOptions{MinExamples: 1, Imbalance: 4}, []string{"category b has 1 examples, the largest category has 8"}},
+		{LintOptions{MinExamples: 1, Imbalance: 10}, nil},
+		{LintOptions{MinExamples: 1}, nil},
+	}
+	for _, test := range tests {
+		var got []string
+		for _, issue := range Lint("chatss.txt", dataset, "", nil, test.options) {
+			if issue.Line == 0 {
+				got = append(got, issue.Msg)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%+v: issues %q, want %q", test.options, got, test.want)
+		}
+	}
+}
+
+func TestLintPreprocessing(t *testing.T) {
+	dataset := &Dataset{Examples: []Example{
+		{Text: "quiero una pizza", Category: "food", Line: 1},
+		{Text: "dame pizzas", Category: "food", Line: 2},
+		{Text: "tengo hambre", Category: "food", Line: 3},
+	}}
+	tests := []struct {
+		name          string
+		preprocessing Preprocessing
+		want          []string
+	}{
+		{"default", DefaultPreprocessing(), []string{"pizza", "quiero", "dame", "pizzas", "hambre", "tengo"}},
+		{"no preprocessing", Preprocessing{}, []string{"pizza", "quiero", "dame", "pizzas", "hambre", "tengo"}},
+		//With the stems of the training pizza and pizzas are the same word
+		{"stem", NewPreprocessing(DefaultPreprocessing().Steps, true, nil, nil), []string{"quier", "dam", "hambr", "teng"}},
+	}
+	for _, test := range tests {
+		options := DefaultLintOptions()
+		options.Preprocessing = test.preprocessing
+		var got []string
+		for _, issue := range Lint("chatss.txt", dataset, "", nil, options) {
+			var word string
+			if _, err := fmt.Sscanf(issue.Msg, "word %q appears only once", &word); err == nil {
+				got = append(got, word)
+			}
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: words %q, want %q", test.name, got, test.want)
+		}
+	}
+}
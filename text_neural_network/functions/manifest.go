@@ -0,0 +1,46 @@
+package functions
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+)
+
+//Manifest records how a model was trained: with the same seed, dataset and
+//hyperparameters the training gives exactly the same model
+type Manifest struct {
+	Seed int64
+	//File of the sentences the model was trained with, and the sha256 of its content
+	Dataset       string
+	DatasetSHA256 string
+	Config        TrainConfig
+	//Epoch the training was resumed from, when it was continued from a checkpoint
+	ResumedFrom int `json:",omitempty"`
+	//Fraction of the sentences held out when the model is the best of a tune search, it was trained without them.
+	//Tune with the same seed holds out the same sentences again
+	Holdout float64 `json:",omitempty"`
+}
+
+//This function gets the sha256 of the content of a dataset file, as hexadecimal
+func DatasetHash(file string) (string, error) {
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return "", err
+	}
+	return checksum(content), nil
+}
+
+//This function gets the name of the manifest of a model file, model.json has model.manifest.json
+func ManifestFile(model string) string {
+	return strings.TrimSuffix(model, filepath.Ext(model)) + ".manifest.json"
+}
+
+//This function saves the manifest of a model next to the model file
+func SaveManifest(model string, manifest Manifest) error {
+	content, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(ManifestFile(model), content, 0644)
+}
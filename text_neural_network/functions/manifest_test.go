@@ -0,0 +1,98 @@
+package functions
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestSeed(t *testing.T) {
+	_, x, y, words, categories := toyData()
+	tests := []struct {
+		name   string
+		config func(config *TrainConfig)
+	}{
+		{"full batch", func(config *TrainConfig) {}},
+		{"mini-batches", func(config *TrainConfig) { config.BatchSize = 3 }},
+		{"dropout", func(config *TrainConfig) { config.Dropout, config.DropoutPercent = true, 0.3 }},
+		{"validation", func(config *TrainConfig) { config.Validation = 0.25 }},
+	}
+	for _, test := range tests {
+		config := toyConfig()
+		config.Epochs = 50
+		test.config(&config)
+		var models []*Model
+		for _, seed := range []int64{7, 7, 8} {
+			config.Seed = seed
+			model, err := Train(x, y, config, words, categories)
+			if err != nil {
+				t.Fatal(err)
+			}
+			models = append(models, model)
+		}
+		//The same seed trains exactly the same model, another seed a different one
+		for i := range models[0].Weights {
+			if !mat.Equal(models[0].Weights[i], models[1].Weights[i]) {
+				t.Errorf("%s: the same seed trained different weights %d", test.name, i)
+			}
+		}
+		if mat.Equal(models[0].Weights[0], models[2].Weights[0]) {
+			t.Errorf("%s: another seed trained the same weights", test.name)
+		}
+	}
+}
+
+func TestManifestFile(t *testing.T) {
+	tests := []struct {
+		model string
+		want  string
+	}{
+		{"model.json", "model.manifest.json"},
+		{"models/bot.gob", "models/bot.manifest.json"},
+		{"v1.2/model", "v1.2/model.manifest.json"},
+		{"model.v2.bin", "model.v2.manifest.json"},
+	}
+	for _, test := range tests {
+		if got := ManifestFile(test.model); got != test.want {
+			t.Errorf("ManifestFile(%q) = %q, want %q", test.model, got, test.want)
+		}
+	}
+}
+
+func TestSaveManifest(t *testing.T) {
+	dir := t.TempDir()
+	dataset := filepath.Join(dir, "chatss.txt")
+	if err := ioutil.WriteFile(dataset, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	hash, err := DatasetHash(dataset)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"; hash != want {
+		t.Errorf("DatasetHash = %s, want %s", hash, want)
+	}
+	manifest := Manifest{Seed: 42, Dataset: dataset, DatasetSHA256: hash, Config: toyConfig(), ResumedFrom: 10, Holdout: 0.2}
+	model := filepath.Join(dir, "model.json")
+	if err := SaveManifest(model, manifest); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(filepath.Join(dir, "model.manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Manifest
+	if err := json.Unmarshal(content, &saved); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved, manifest) {
+		t.Errorf("saved manifest %+v, want %+v", saved, manifest)
+	}
+	if _, err := DatasetHash(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("DatasetHash of a missing file didn't fail")
+	}
+}
@@ -0,0 +1,144 @@
+package functions
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/gob"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+//Version of the layout of the model files. Files without a version are the ones saved
+//before it existed, just the model with no envelope
+const MODEL_VERSION = 1
+
+//envelope is the layout of a model file: the model, with the version and the sha256 of its encoding
+type envelope struct {
+	Version  int
+	Checksum string
+	Model    json.RawMessage
+}
+
+//This function tells if a model file is binary from its extension, .gob or .bin, otherwise it is json
+func isBinary(file string) bool {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".gob", ".bin":
+		return true
+	}
+	return false
+}
+
+//This function encodes the data of a model inside an envelope with its checksum, as json or
+//as gob when binary. The binary file is smaller and faster to read, the json can be read by anyone
+func sealModel(data synapse, binary bool) ([]byte, error) {
+	if binary {
+		var payload, content bytes.Buffer
+		if err := gob.NewEncoder(&payload).Encode(data); err != nil {
+			return nil, err
+		}
+		file := envelope{Version: MODEL_VERSION, Checksum: checksum(payload.Bytes()), Model: payload.Bytes()}
+		if err := gob.NewEncoder(&content).Encode(file); err != nil {
+			return nil, err
+		}
+		return content.Bytes(), nil
+	}
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(envelope{Version: MODEL_VERSION, Checksum: checksum(payload), Model: payload})
+}
+
+//This function decodes the content of a model file, json or binary, checking its version and its checksum
+func openModel(content []byte, binary bool) (synapse, error) {
+	var data synapse
+	var file envelope
+	if binary {
+		if err := gob.NewDecoder(bytes.NewReader(content)).Decode(&file); err != nil {
+			return data, fmt.Errorf("it is not a valid binary model file: %v", err)
+		}
+	} else if err := json.Unmarshal(content, &file); err != nil {
+		return data, fmt.Errorf("it is not a valid model file: %v", err)
+	}
+	switch {
+	case !binary && file.Version == 0 && file.Model == nil:
+		//Json files saved before the envelope are the model alone
+		if err := json.Unmarshal(content, &data); err != nil {
+			return data, fmt.Errorf("it is not a valid model file: %v", err)
+		}
+		return data, nil
+	case file.Version < 1 || file.Version > MODEL_VERSION:
+		return data, fmt.Errorf("format version %d is not supported, this program reads up to version %d", file.Version, MODEL_VERSION)
+	case file.Model == nil:
+		return data, fmt.Errorf("it has no model")
+	}
+	payload := []byte(file.Model)
+	if !binary {
+		//The checksum is of the compact json, so indenting the file doesn't break it
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, file.Model); err != nil {
+			return data, fmt.Errorf("it is not a valid model file: %v", err)
+		}
+		payload = compact.Bytes()
+	}
+	if sum := checksum(payload); sum != file.Checksum {
+		return data, fmt.Errorf("the checksum of the model is %s but the file says %s, the file is corrupt or was edited", sum, file.Checksum)
+	}
+	if binary {
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&data); err != nil {
+			return data, fmt.Errorf("it is not a valid binary model file: %v", err)
+		}
+	} else if err := json.Unmarshal(payload, &data); err != nil {
+		return data, fmt.Errorf("it is not a valid model file: %v", err)
+	}
+	return data, nil
+}
+
+//This function gets the sha256 of some content, as hexadecimal
+func checksum(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+//This function checks the weights of every layer take the outputs of the previous one,
+//the first takes the words and the last gives the categories, and that the activations,
+//preprocessing steps and features are known
+func (data synapse) validate() error {
+	if len(data.Synapses) == 0 {
+		return fmt.Errorf("it has no weights")
+	}
+	if len(data.Activations) != len(data.Synapses) {
+		return fmt.Errorf("it has %d weights matrixes but %d activations", len(data.Synapses), len(data.Activations))
+	}
+	inputs := len(data.Words)
+	for i, s := range data.Synapses {
+		if s.Rows != inputs {
+			return fmt.Errorf("weights matrix %d has %d rows, it should have %d", i, s.Rows, inputs)
+		}
+		if s.Cols <= 0 || len(s.Data) != s.Rows*s.Cols {
+			return fmt.Errorf("weights matrix %d is %dx%d but has %d values", i, s.Rows, s.Cols, len(s.Data))
+		}
+		switch data.Activations[i] {
+		case SIGMOID, TANH, RELU, SOFTMAX:
+		default:
+			return fmt.Errorf("unknown activation %q on layer %d", data.Activations[i], i)
+		}
+		inputs = s.Cols
+	}
+	if inputs != len(data.Categories) {
+		return fmt.Errorf("the output layer has %d neurons for %d categories", inputs, len(data.Categories))
+	}
+	if data.MaxDistance < 0 {
+		return fmt.Errorf("the typos max distance is %d, it can't be negative", data.MaxDistance)
+	}
+	if data.Threshold < 0 || data.Threshold >= 1 {
+		return fmt.Errorf("the threshold is %v, it must be at least 0 and less than 1", data.Threshold)
+	}
+	if err := data.Features.validate(len(data.Words)); err != nil {
+		return err
+	}
+	return data.Preprocessing.validate()
+}
@@ -0,0 +1,272 @@
+package functions
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//This function trains a small model of the toy examples, to save and load it
+func toyModel(t *testing.T) *Model {
+	t.Helper()
+	_, x, y, words, categories := toyData()
+	config := toyConfig()
+	config.Epochs = 20
+	model, err := Train(x, y, config, words, categories)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return model
+}
+
+//This function checks a loaded model is the one that was saved
+func checkSameModel(t *testing.T, got *Model, want *Model) {
+	t.Helper()
+	if len(got.Weights) != len(want.Weights) {
+		t.Fatalf("%d weights matrixes, want %d", len(got.Weights), len(want.Weights))
+	}
+	for i := range want.Weights {
+		if !mat.Equal(got.Weights[i], want.Weights[i]) {
+			t.Errorf("weights %d changed", i)
+		}
+	}
+	if !reflect.DeepEqual(newSynapse(got), newSynapse(want)) {
+		t.Errorf("model %+v, want %+v", newSynapse(got), newSynapse(want))
+	}
+}
+
+func TestSaveLoadFile(t *testing.T) {
+	model := toyModel(t)
+	file := filepath.Join(t.TempDir(), "model.json")
+	if err := SaveFile(file, model); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameModel(t, loaded, model)
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var file_envelope envelope
+	if err := json.Unmarshal(content, &file_envelope); err != nil {
+		t.Fatal(err)
+	}
+	if file_envelope.Version != MODEL_VERSION || file_envelope.Checksum != checksum(file_envelope.Model) {
+		t.Errorf("the file has version %d and checksum %s", file_envelope.Version, file_envelope.Checksum)
+	}
+	//A model that answers every sentence with noanswer is not a valid one
+	model.Threshold = 1
+	if err := SaveFile(file, model); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadFile(file); !errors.Is(err, ErrCorruptModel) {
+		t.Errorf("a model with threshold 1: error = %v, want %v", err, ErrCorruptModel)
+	}
+}
+
+func TestSaveFileReplace(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "model.json")
+	if err := SaveFile(file, toyModel(t)); err != nil {
+		t.Fatal(err)
+	}
+	//Saving again replaces the model, through a temporary file that is not left behind
+	model := toyModel(t)
+	model.Alpha = 0.25
+	if err := SaveFile(file, model); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameModel(t, loaded, model)
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 1 || files[0].Name() != "model.json" || files[0].Mode().Perm() != 0644 {
+		for _, f := range files {
+			t.Errorf("the directory has %s with mode %v, want only model.json with mode 0644", f.Name(), f.Mode().Perm())
+		}
+	}
+	if err := SaveFile(filepath.Join(dir, "missing", "model.json"), model); err == nil {
+		t.Error("SaveFile into a missing directory didn't fail")
+	}
+}
+
+func TestOpenModel(t *testing.T) {
+	model := toyModel(t)
+	content, err := sealModel(newSynapse(model), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		change func(content []byte) []byte
+		//Part of the error, empty if the file can be read
+		err string
+	}{
+		{"untouched", func(content []byte) []byte { return content }, ""},
+		{"indented", func(content []byte) []byte {
+			var indented bytes.Buffer
+			json.Indent(&indented, content, "", "  ")
+			return indented.Bytes()
+		}, ""},
+		{"a word edited", func(content []byte) []byte {
+			return bytes.Replace(content, []byte(`"pizza"`), []byte(`"pasta"`), 1)
+		}, "checksum"},
+		{"newer version", func(content []byte) []byte {
+			return bytes.Replace(content, []byte(`"Version":1`), []byte(`"Version":2`), 1)
+		}, "version 2 is not supported"},
+		{"no model", func(content []byte) []byte { return []byte(`{"Version":1,"Checksum":""}`) }, "it has no model"},
+		{"not json", func(content []byte) []byte { return content[:len(content)/2] }, "not a valid model file"},
+	}
+	for _, test := range tests {
+		_, err := openModel(test.change(content), false)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: error = %v, want %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestLegacyModel(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		activations []string
+		err         bool
+	}{
+		{"two fixed layers", `{"Synapse_0": {"Rows": 2, "Cols": 1, "Stride": 1, "Data": [0.5, -0.5]},
+			"Synapse_1": {"Rows": 1, "Cols": 2, "Stride": 2, "Data": [-1, 1]},
+			"Words": ["hola", "adios"], "Categories": ["goodbye", "greeting"]}`, []string{SIGMOID, SIGMOID}, false},
+		{"softmax output", `{"Synapse_0": {"Rows": 2, "Cols": 1, "Stride": 1, "Data": [0.5, -0.5]},
+			"Synapse_1": {"Rows": 1, "Cols": 2, "Stride": 2, "Data": [-1, 1]},
+			"Words": ["hola", "adios"], "Categories": ["goodbye", "greeting"], "Output": "softmax"}`, []string{SIGMOID, SOFTMAX}, false},
+		{"any number of layers", `{"Synapses": [{"Rows": 2, "Cols": 2, "Stride": 2, "Data": [0, 1, 1, 0]}],
+			"Activations": ["tanh"], "Words": ["hola", "adios"], "Categories": ["goodbye", "greeting"]}`, []string{TANH}, false},
+		{"layers that don't fit", `{"Synapse_0": {"Rows": 2, "Cols": 3, "Stride": 3, "Data": [0, 0, 0, 0, 0, 0]},
+			"Synapse_1": {"Rows": 1, "Cols": 2, "Stride": 2, "Data": [-1, 1]},
+			"Words": ["hola", "adios"], "Categories": ["goodbye", "greeting"]}`, nil, true},
+		{"no weights", `{"Words": ["hola"], "Categories": ["greeting"]}`, nil, true},
+	}
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), "model.json")
+		if err := ioutil.WriteFile(file, []byte(test.content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		model, err := LoadFile(file)
+		if (err != nil) != test.err {
+			t.Errorf("%s: error = %v, want error %t", test.name, err, test.err)
+			continue
+		}
+		if test.err {
+			if !errors.Is(err, ErrCorruptModel) {
+				t.Errorf("%s: error = %v, want %v", test.name, err, ErrCorruptModel)
+			}
+			continue
+		}
+		//The old models used the default preprocessing and binary features
+		if !reflect.DeepEqual(model.Activations, test.activations) || !reflect.DeepEqual(model.Preprocessing, DefaultPreprocessing()) || model.Features.Name != BINARY {
+			t.Errorf("%s: activations %v, preprocessing %v and features %v", test.name, model.Activations, model.Preprocessing, model.Features)
+		}
+		if got := model.Category("hola", false).Key; got != "greeting" {
+			t.Errorf("%s: hola is %s, want greeting", test.name, got)
+		}
+	}
+}
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		file string
+		want bool
+	}{
+		{"model.json", false},
+		{"model.gob", true},
+		{"model.bin", true},
+		{"MODEL.GOB", true},
+		{"models.bin/model", false},
+		{"model", false},
+	}
+	for _, test := range tests {
+		if got := isBinary(test.file); got != test.want {
+			t.Errorf("isBinary(%q) = %t, want %t", test.file, got, test.want)
+		}
+	}
+}
+
+func TestBinaryModel(t *testing.T) {
+	model := toyModel(t)
+	dir := t.TempDir()
+	for _, name := range []string{"model.gob", "model.bin", "model.json"} {
+		file := filepath.Join(dir, name)
+		if err := SaveFile(file, model); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := LoadFile(file)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		checkSameModel(t, loaded, model)
+	}
+	//Converting from one format to the other keeps the same model
+	loaded, err := LoadFile(filepath.Join(dir, "model.gob"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	converted := filepath.Join(dir, "converted.json")
+	if err := SaveFile(converted, loaded); err != nil {
+		t.Fatal(err)
+	}
+	json_content, _ := ioutil.ReadFile(filepath.Join(dir, "model.json"))
+	converted_content, _ := ioutil.ReadFile(converted)
+	if !bytes.Equal(json_content, converted_content) {
+		t.Error("the json converted from the binary model is not the one saved from the model")
+	}
+
+	binary, err := sealModel(newSynapse(model), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		content []byte
+		binary  bool
+		err     string
+	}{
+		{"binary", binary, true, ""},
+		{"binary read as json", binary, false, "not a valid model file"},
+		{"json read as binary", json_content, true, "not a valid binary model file"},
+		{"cut binary", binary[:len(binary)/2], true, "not a valid binary model file"},
+	}
+	for _, test := range tests {
+		_, err := openModel(test.content, test.binary)
+		if test.err == "" && err != nil || test.err != "" && (err == nil || !strings.Contains(err.Error(), test.err)) {
+			t.Errorf("%s: error = %v, want %q", test.name, err, test.err)
+		}
+	}
+	//A change on the binary model breaks its checksum
+	var file envelope
+	if err := gob.NewDecoder(bytes.NewReader(binary)).Decode(&file); err != nil {
+		t.Fatal(err)
+	}
+	file.Model = bytes.Replace(file.Model, []byte("pizza"), []byte("pasta"), 1)
+	var edited bytes.Buffer
+	if err := gob.NewEncoder(&edited).Encode(file); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openModel(edited.Bytes(), true); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("edited binary model: error = %v, want a checksum error", err)
+	}
+}
@@ -0,0 +1,234 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//Names of the activations of the layers, as saved on the model file
+const (
+	SIGMOID = "sigmoid"
+	TANH    = "tanh"
+	RELU    = "relu"
+	SOFTMAX = "softmax"
+)
+
+//Layer is a dense hidden layer, its number of neurons and its activation
+type Layer struct {
+	Size       int
+	Activation string
+}
+
+func (l Layer) String() string {
+	return fmt.Sprintf("%d:%s", l.Size, l.Activation)
+}
+
+//Model is a trained network: a weights matrix and an activation for every layer,
+//plus the words and categories the input and output neurons stand for
+type Model struct {
+	Weights []*mat.Dense
+	//One for every weights matrix, the last one is the activation of the output layer
+	Activations []string
+	Words       []string
+	Categories  []string
+	//How the model was trained, to be able to continue training it
+	Alpha     float64
+	Schedule  Schedule
+	Optimizer OptimizerState
+	Metrics   TrainMetrics
+	//When and with what the model was trained, and how the sentences are turned into words
+	Created       time.Time
+	DatasetSHA256 string
+	Config        *TrainConfig
+	Preprocessing Preprocessing
+	//How the counts of the words are turned into the input of the network, and what it learned to do it
+	Features Features
+	//Words of a sentence this many edits from a word of the vocabulary are read as that word, 0 to only read exact words
+	MaxDistance int
+	//Score the best category needs for a sentence to be understood, ERROR_THRESHOLD if it is 0
+	Threshold float64
+	//Index of the vocabulary to find the closest word to a typo, built the first time it is needed
+	index      *bkTree
+	index_once sync.Once
+	//Normalizers of the preprocessing, built the first time a sentence is read
+	steps      pipeline
+	steps_once sync.Once
+}
+
+//This function reads a list of hidden layers written as "size:activation,size:activation",
+//the activation can be left out and it will be sigmoid, like "20" or "40:relu,20:tanh"
+func ParseLayers(spec string) ([]Layer, error) {
+	var layers []Layer
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		size_activation := strings.SplitN(part, ":", 2)
+		size, err := strconv.Atoi(strings.TrimSpace(size_activation[0]))
+		if err != nil || size <= 0 {
+			return nil, fmt.Errorf("invalid layer size %q", size_activation[0])
+		}
+		layer := Layer{Size: size, Activation: SIGMOID}
+		if len(size_activation) == 2 {
+			layer.Activation = strings.ToLower(strings.TrimSpace(size_activation[1]))
+		}
+		switch layer.Activation {
+		case SIGMOID, TANH, RELU:
+		default:
+			return nil, fmt.Errorf("invalid activation %q for a hidden layer", layer.Activation)
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+//This function feeds the whole input matrix through the network.
+//Dropout is only used while training, here every neuron takes part
+func (m *Model) forward(x *mat.Dense) *mat.Dense {
+	layer := x
+	for i, w := range m.Weights {
+		prod := new(mat.Dense)
+		prod.Product(layer, w)
+		layer = activate(prod, m.Activations[i])
+	}
+	return layer
+}
+
+//This function gets the score of every category for a sentence, highest first.
+//With a softmax model the scores are a probability distribution over the categories
+func (m *Model) Predict(sentence string, details bool) Entries {
+	es, _ := m.predict(sentence, details)
+	return es
+}
+
+//This function gets the score of every category for a sentence, highest first, and the typos corrected to read it
+func (m *Model) predict(sentence string, details bool) (Entries, []Correction) {
+	//Get the prediction of the ANN, and save it
+	result, corrections := think(sentence, details, m)
+	var es Entries
+	for i, category := range m.Categories {
+		es = append(es, Entry{Val: result.At(0, i), Key: category})
+	}
+	sort.Stable(sort.Reverse(es))
+	return es, corrections
+}
+
+//This function gets the category of a sentence, the one with the highest score, with the typos corrected to read it.
+//If no category is greater than the threshold of the model the sentence is not understood, so it is noanswer,
+//with the score of the category that was closest
+func (m *Model) Category(sentence string, details bool) Entry {
+	es, corrections := m.predict(sentence, details)
+	best := es[0]
+	if best.Val <= m.threshold() {
+		best.Key = "noanswer"
+	}
+	best.Corrections = corrections
+	return best
+}
+
+//This function finds the word of the vocabulary a word that is not on it was meant to be, at most
+//MaxDistance edits away. Short words are never corrected, a couple of edits turn them into any other
+func (m *Model) correct(word string) (Correction, bool) {
+	if m.MaxDistance <= 0 {
+		return Correction{}, false
+	}
+	m.index_once.Do(func() { m.index = newBKTree(m.Words) })
+	c, ok := m.index.closest(word, m.MaxDistance)
+	if !ok || 2*c.Distance >= len([]rune(word)) {
+		return Correction{}, false
+	}
+	return c, true
+}
+
+//This function gets the score a category needs for the sentence to be understood,
+//ERROR_THRESHOLD for the models saved before it could be chosen
+func (m *Model) threshold() float64 {
+	if m.Threshold <= 0 {
+		return ERROR_THRESHOLD
+	}
+	return m.Threshold
+}
+
+//This function gets the normalizers of the preprocessing of the model, they are built only once
+//and shared by every sentence, the preprocessing of a model never changes
+func (m *Model) pipeline() pipeline {
+	m.steps_once.Do(func() { m.steps = m.Preprocessing.build() })
+	return m.steps
+}
+
+//This function gets the feature extractor of the model, binary if its features are unknown,
+//which only happens to a model that was not loaded from a file or trained
+func (m *Model) extractor() FeatureExtractor {
+	extractor, err := RestoreFeatures(m.Features)
+	if err != nil {
+		return binaryFeatures{}
+	}
+	return extractor
+}
+
+//This function sets a weights matrix with random values between -1 and 1
+func randomWeights(rows int, cols int, rng *rand.Rand) *mat.Dense {
+	data := make([]float64, rows*cols)
+	for i := range data {
+		data[i] = 2*rng.Float64() - 1
+	}
+	return mat.NewDense(rows, cols, data)
+}
+
+//This function gets an inverted dropout mask: every neuron is dropped with probability rate,
+//and the ones kept are scaled by 1/(1-rate) so the expected value of the layer is the
+//same with and without dropout, that way nothing has to change to use the network
+func dropoutMask(rows int, cols int, rate float64, rng *rand.Rand) *mat.Dense {
+	data := make([]float64, rows*cols)
+	for i := range data {
+		if rng.Float64() >= rate {
+			data[i] = 1 / (1 - rate)
+		}
+	}
+	return mat.NewDense(rows, cols, data)
+}
+
+//This function applies the activation with the given name to a matrix
+func activate(v *mat.Dense, name string) *mat.Dense {
+	output := new(mat.Dense)
+	switch name {
+	case SOFTMAX:
+		return softmax(v)
+	case TANH:
+		output.Apply(func(i, j int, v float64) float64 { return math.Tanh(v) }, v)
+	case RELU:
+		output.Apply(func(i, j int, v float64) float64 { return math.Max(0, v) }, v)
+	default:
+		return sigmoid(v)
+	}
+	return output
+}
+
+//This function applies the derivative of the activation with the given name,
+//written in terms of the already activated values of the layer
+func derivative(v *mat.Dense, name string) *mat.Dense {
+	output := new(mat.Dense)
+	switch name {
+	case TANH:
+		output.Apply(func(i, j int, v float64) float64 { return 1 - v*v }, v)
+	case RELU:
+		output.Apply(func(i, j int, v float64) float64 {
+			if v > 0 {
+				return 1
+			}
+			return 0
+		}, v)
+	default:
+		return sig_to_dev(v)
+	}
+	return output
+}
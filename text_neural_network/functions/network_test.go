@@ -0,0 +1,181 @@
+package functions
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestParseLayers(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []Layer
+		err  bool
+	}{
+		{"20", []Layer{{20, SIGMOID}}, false},
+		{"40:relu,20:tanh", []Layer{{40, RELU}, {20, TANH}}, false},
+		{" 10 : ReLU , 5 ", []Layer{{10, RELU}, {5, SIGMOID}}, false},
+		{"8,,4:sigmoid,", []Layer{{8, SIGMOID}, {4, SIGMOID}}, false},
+		{"", nil, false},
+		{"0", nil, true},
+		{"-3", nil, true},
+		{"ten", nil, true},
+		{"10:softmax", nil, true},
+		{"10:swish", nil, true},
+	}
+	for _, test := range tests {
+		got, err := ParseLayers(test.spec)
+		if (err != nil) != test.err {
+			t.Errorf("ParseLayers(%q) error = %v, want error %t", test.spec, err, test.err)
+			continue
+		}
+		if !test.err && !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseLayers(%q) = %v, want %v", test.spec, got, test.want)
+		}
+	}
+}
+
+func TestTrainLayers(t *testing.T) {
+	db, x, y, words, categories := toyData()
+	tests := []struct {
+		spec string
+		//Rows and columns of every weights matrix, from the input to the output
+		dims [][2]int
+	}{
+		{"", [][2]int{{len(words), len(categories)}}},
+		{"8", [][2]int{{len(words), 8}, {8, len(categories)}}},
+		{"24:relu,12:tanh", [][2]int{{len(words), 24}, {24, 12}, {12, len(categories)}}},
+	}
+	for _, test := range tests {
+		layers, err := ParseLayers(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config := toyConfig()
+		config.Layers = layers
+		config.Alpha = 0.1
+		config.Epochs = 1000
+		model, err := Train(x, y, config, words, categories)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(model.Weights) != len(test.dims) || len(model.Activations) != len(test.dims) {
+			t.Errorf("%q: %d weights matrixes and %d activations, want %d", test.spec, len(model.Weights), len(model.Activations), len(test.dims))
+			continue
+		}
+		for i, w := range model.Weights {
+			if r, c := w.Dims(); r != test.dims[i][0] || c != test.dims[i][1] {
+				t.Errorf("%q: weights %d are %dx%d, want %dx%d", test.spec, i, r, c, test.dims[i][0], test.dims[i][1])
+			}
+		}
+		for i, layer := range layers {
+			if model.Activations[i] != layer.Activation {
+				t.Errorf("%q: activation %d is %s, want %s", test.spec, i, model.Activations[i], layer.Activation)
+			}
+		}
+		t.Run(test.spec, func(t *testing.T) { checkLearned(t, model, db) })
+	}
+}
+
+func TestDropoutMask(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, rate := range []float64{0, 0.2, 0.5, 0.9} {
+		mask := dropoutMask(200, 100, rate, rng)
+		kept, dropped := 0, 0
+		var sum float64
+		for _, v := range mask.RawMatrix().Data {
+			switch v {
+			case 0:
+				dropped++
+			case 1 / (1 - rate):
+				kept++
+			default:
+				t.Fatalf("rate %v: the mask has %v, want 0 or %v", rate, v, 1/(1-rate))
+			}
+			sum += v
+		}
+		//About rate of the neurons are dropped, and the mean of the mask is about one
+		if got := float64(dropped) / float64(kept+dropped); math.Abs(got-rate) > 0.02 {
+			t.Errorf("rate %v: dropped %v of the neurons", rate, got)
+		}
+		if mean := sum / float64(kept+dropped); math.Abs(mean-1) > 0.05 {
+			t.Errorf("rate %v: the mean of the mask is %v, want about 1", rate, mean)
+		}
+	}
+}
+
+func TestTrainDropout(t *testing.T) {
+	db, x, y, words, categories := toyData()
+	tests := []struct {
+		percent float64
+		err     bool
+	}{
+		{0, false},
+		{0.2, false},
+		{0.5, false},
+		{1, true},
+		{-0.1, true},
+	}
+	for _, test := range tests {
+		config := toyConfig()
+		config.Layers = []Layer{{Size: 16, Activation: SIGMOID}}
+		config.Dropout, config.DropoutPercent = true, test.percent
+		model, err := Train(x, y, config, words, categories)
+		if (err != nil) != test.err {
+			t.Errorf("dropout %v: error = %v, want error %t", test.percent, err, test.err)
+			continue
+		}
+		if !test.err {
+			checkLearned(t, model, db)
+		}
+	}
+}
+
+func TestCategoryThreshold(t *testing.T) {
+	tests := []struct {
+		sentence  string
+		threshold float64
+		want      string
+	}{
+		{"hola", 0, "greeting"},
+		{"hola", 0.5, "greeting"},
+		{"nada", 0, "noanswer"},
+		//Not even a sure category is understood when the threshold is higher than its score
+		{"hola", 0.99999, "noanswer"},
+	}
+	for _, test := range tests {
+		model := keywordModel()
+		model.Threshold = test.threshold
+		got := model.Category(test.sentence, false)
+		//The noanswer score is the one of the closest category, not a made up one
+		if best := model.Predict(test.sentence, false)[0].Val; got.Key != test.want || got.Val != best {
+			t.Errorf("Category(%q) with threshold %v = %s %v, want %s %v", test.sentence, test.threshold, got.Key, got.Val, test.want, best)
+		}
+	}
+}
+
+func TestTrainThreshold(t *testing.T) {
+	_, x, y, words, categories := toyData()
+	tests := []struct {
+		threshold float64
+		err       bool
+	}{
+		{0, false},
+		{0.3, false},
+		{1, true},
+		{-0.1, true},
+	}
+	for _, test := range tests {
+		config := toyConfig()
+		config.Epochs, config.Threshold = 10, test.threshold
+		model, err := Train(x, y, config, words, categories)
+		if (err != nil) != test.err {
+			t.Errorf("threshold %v: error = %v, want error %t", test.threshold, err, test.err)
+			continue
+		}
+		if !test.err && model.Threshold != test.threshold {
+			t.Errorf("threshold %v: the model has %v", test.threshold, model.Threshold)
+		}
+	}
+}
@@ -0,0 +1,550 @@
+package functions
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//Formats of other NLU tools the datasets can be imported from and exported to
+const (
+	RASA       = "rasa"
+	RASA_MD    = "rasa_md"
+	DIALOGFLOW = "dialogflow"
+)
+
+//Name of the intent Dialogflow answers with when it doesn't understand, our noanswer
+const DIALOGFLOW_FALLBACK = "Default Fallback Intent"
+
+//This function guesses the NLU format of a file from its extension: .yml and .yaml are Rasa,
+//.md is Rasa Markdown, and a .zip file or a directory is a Dialogflow agent
+func NLUFormat(file string) (string, error) {
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".yml", ".yaml":
+		return RASA, nil
+	case ".md":
+		return RASA_MD, nil
+	case ".zip":
+		return DIALOGFLOW, nil
+	}
+	if info, err := os.Stat(file); err == nil && info.IsDir() {
+		return DIALOGFLOW, nil
+	}
+	return "", fmt.Errorf("can't tell the format of %s, it must be %s, %s or %s", file, RASA, RASA_MD, DIALOGFLOW)
+}
+
+//This function reads a dataset of another NLU tool, in the given format
+func Import(file string, format string) (*Dataset, error) {
+	switch format {
+	case RASA, RASA_MD:
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if format == RASA {
+			return ReadRasa(f)
+		}
+		return ReadRasaMarkdown(f)
+	case DIALOGFLOW:
+		//An agent is exported as a zip file, that can also be extracted on a directory
+		if strings.EqualFold(filepath.Ext(file), ".zip") {
+			archive, err := zip.OpenReader(file)
+			if err != nil {
+				return nil, err
+			}
+			defer archive.Close()
+			return ReadDialogflow(archive)
+		}
+		return ReadDialogflow(os.DirFS(file))
+	}
+	return nil, fmt.Errorf("unknown format %q, it must be %s, %s or %s", format, RASA, RASA_MD, DIALOGFLOW)
+}
+
+//This function saves a dataset in the format of another NLU tool. Dialogflow agents are
+//saved as a zip file when the name ends in .zip, and on a directory otherwise.
+//It gets how many examples were left out for having no sentence, like the noanswer ones,
+//the other tools have no examples without a sentence
+func Export(file string, format string, lang string, dataset *Dataset) (int, error) {
+	_, _, empty := groupExamples(dataset)
+	switch format {
+	case RASA:
+		return empty, writeFile(file, func(w io.Writer) error { return WriteRasa(w, dataset) })
+	case RASA_MD:
+		return empty, writeFile(file, func(w io.Writer) error { return WriteRasaMarkdown(w, dataset) })
+	case DIALOGFLOW:
+		return empty, WriteDialogflow(file, lang, dataset)
+	}
+	return 0, fmt.Errorf("unknown format %q, it must be %s, %s or %s", format, RASA, RASA_MD, DIALOGFLOW)
+}
+
+//This function writes a file on a temporary file next to it, and moves it to its name once it is
+//complete, so a failed export or model save never leaves half a file behind
+func writeFile(file string, write func(w io.Writer) error) error {
+	f, err := ioutil.TempFile(filepath.Dir(file), "."+filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	//The temporary files are only readable by their owner, the files they replace were not
+	if err == nil {
+		err = f.Chmod(0644)
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if close_err := f.Close(); err == nil {
+		err = close_err
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), file)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+//This function gets the intents of a dataset in the order they first appear, with their examples,
+//and how many examples were left out for having no sentence, like the noanswer ones.
+//The generated ones are left out too, the other tools have no way to tell them apart
+func groupExamples(dataset *Dataset) ([]string, map[string][]string, int) {
+	var intents []string
+	examples := make(map[string][]string)
+	add := func(intent string) {
+		if _, ok := examples[intent]; !ok {
+			intents = append(intents, intent)
+			examples[intent] = nil
+		}
+	}
+	empty := 0
+	for _, example := range dataset.Examples {
+		if example.Generated {
+			continue
+		}
+		add(example.Category)
+		if example.Text == "" {
+			empty++
+			continue
+		}
+		examples[example.Category] = append(examples[example.Category], example.Text)
+	}
+	//Intents with only responses go at the end
+	var rest []string
+	for intent := range dataset.Responses {
+		if _, ok := examples[intent]; !ok {
+			rest = append(rest, intent)
+		}
+	}
+	sort.Strings(rest)
+	for _, intent := range rest {
+		add(intent)
+	}
+	return intents, examples, empty
+}
+
+//This function adds the responses to a dataset, with a noanswer example when it only has responses
+//so the category still exists
+func addResponses(dataset *Dataset, responses map[string][]string) {
+	seen := make(map[string]bool)
+	for _, example := range dataset.Examples {
+		seen[example.Category] = true
+	}
+	dataset.Responses = make(map[string][]string)
+	var intents []string
+	for intent, texts := range responses {
+		if len(texts) > 0 {
+			dataset.Responses[intent] = texts
+			intents = append(intents, intent)
+		}
+	}
+	sort.Strings(intents)
+	for _, intent := range intents {
+		if intent == "noanswer" && !seen[intent] {
+			dataset.Examples = append(dataset.Examples, Example{Category: intent})
+		}
+	}
+}
+
+//Rasa writes entities inside the examples as [text](entity) or [text]{"entity": ...}, only the text is kept
+var rasaEntity = regexp.MustCompile(`\[([^\]]*)\](\([^)]*\)|\{[^}]*\})`)
+
+//This function gets the sentences of the examples block of a Rasa intent, one on every "- " line
+func rasaExamples(block string) []string {
+	var sentences []string
+	for _, line := range strings.Split(block, "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "-") {
+			continue
+		}
+		sentence := strings.TrimSpace(rasaEntity.ReplaceAllString(line[1:], "$1"))
+		if sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
+
+//rasaFile is the layout of a Rasa training data file, the responses are the ones of the domain
+type rasaFile struct {
+	Version   string                         `yaml:"version"`
+	NLU       []rasaIntent                   `yaml:"nlu"`
+	Responses map[string][]map[string]string `yaml:"responses,omitempty"`
+}
+
+//rasaIntent is an item of the nlu list of Rasa, the synonyms, regexes and lookups have no intent
+type rasaIntent struct {
+	Intent   string `yaml:"intent,omitempty"`
+	Examples string `yaml:"examples"`
+}
+
+//This function reads a Rasa YAML training data file. The responses named utter_<intent>
+//are taken as the responses of that intent
+func ReadRasa(r io.Reader) (*Dataset, error) {
+	var data rasaFile
+	if err := yaml.NewDecoder(r).Decode(&data); err != nil && err != io.EOF {
+		return nil, err
+	}
+	dataset := &Dataset{}
+	for _, item := range data.NLU {
+		if item.Intent == "" {
+			continue
+		}
+		for _, sentence := range rasaExamples(item.Examples) {
+			dataset.Examples = append(dataset.Examples, Example{Text: sentence, Category: item.Intent})
+		}
+	}
+	responses := make(map[string][]string)
+	for name, variations := range data.Responses {
+		if !strings.HasPrefix(name, "utter_") {
+			continue
+		}
+		intent := strings.TrimPrefix(name, "utter_")
+		for _, variation := range variations {
+			if text := variation["text"]; text != "" {
+				responses[intent] = append(responses[intent], text)
+			}
+		}
+	}
+	addResponses(dataset, responses)
+	return dataset, nil
+}
+
+//This function writes a dataset as a Rasa YAML training data file, with the responses of every
+//intent named utter_<intent>, the way a Rasa domain has them
+func WriteRasa(w io.Writer, dataset *Dataset) error {
+	data := rasaFile{Version: "3.1"}
+	intents, examples, _ := groupExamples(dataset)
+	for _, intent := range intents {
+		//Rasa needs at least one example for every intent
+		if len(examples[intent]) == 0 {
+			continue
+		}
+		var block strings.Builder
+		for _, sentence := range examples[intent] {
+			fmt.Fprintf(&block, "- %s\n", sentence)
+		}
+		data.NLU = append(data.NLU, rasaIntent{Intent: intent, Examples: block.String()})
+	}
+	if len(dataset.Responses) > 0 {
+		data.Responses = make(map[string][]map[string]string)
+		for intent, texts := range dataset.Responses {
+			for _, text := range texts {
+				data.Responses["utter_"+intent] = append(data.Responses["utter_"+intent], map[string]string{"text": text})
+			}
+		}
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(data); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
+
+//This function reads the "## intent:name" sections of a Rasa Markdown training data file,
+//the format has no responses
+func ReadRasaMarkdown(r io.Reader) (*Dataset, error) {
+	dataset := &Dataset{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	intent := ""
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "##") {
+			//Synonyms, regexes and lookup tables are other kinds of sections, they are skipped
+			intent = ""
+			header := strings.TrimSpace(strings.TrimPrefix(line, "##"))
+			if strings.HasPrefix(header, "intent:") {
+				intent = strings.TrimSpace(strings.TrimPrefix(header, "intent:"))
+			}
+			continue
+		}
+		if intent == "" {
+			continue
+		}
+		for _, sentence := range rasaExamples(line) {
+			dataset.Examples = append(dataset.Examples, Example{Text: sentence, Category: intent, Line: number})
+		}
+	}
+	return dataset, scanner.Err()
+}
+
+//This function writes a dataset as a Rasa Markdown training data file, the responses are left out.
+//So are the examples without a sentence, and the intents that only have those, like noanswer
+func WriteRasaMarkdown(w io.Writer, dataset *Dataset) error {
+	out := bufio.NewWriter(w)
+	intents, examples, _ := groupExamples(dataset)
+	first := true
+	for _, intent := range intents {
+		//An intent is a list of sentences, without them there is nothing to write
+		if len(examples[intent]) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Fprintln(out)
+		}
+		first = false
+		fmt.Fprintf(out, "## intent:%s\n", intent)
+		for _, sentence := range examples[intent] {
+			fmt.Fprintf(out, "- %s\n", sentence)
+		}
+	}
+	return out.Flush()
+}
+
+//dialogflowIntent is the layout of intents/<name>.json of a Dialogflow agent
+type dialogflowIntent struct {
+	Name           string               `json:"name"`
+	Auto           bool                 `json:"auto"`
+	Responses      []dialogflowResponse `json:"responses"`
+	FallbackIntent bool                 `json:"fallbackIntent"`
+}
+
+type dialogflowResponse struct {
+	Messages []dialogflowMessage `json:"messages"`
+}
+
+//dialogflowMessage is a response of an intent, speech is a text or a list of them
+type dialogflowMessage struct {
+	Type   json.RawMessage `json:"type"`
+	Lang   string          `json:"lang"`
+	Speech json.RawMessage `json:"speech"`
+}
+
+//dialogflowExample is an item of intents/<name>_usersays_<lang>.json
+type dialogflowExample struct {
+	Data       []dialogflowPart `json:"data"`
+	IsTemplate bool             `json:"isTemplate"`
+	Count      int              `json:"count"`
+	Lang       string           `json:"lang"`
+}
+
+//dialogflowPart is a piece of an example, the text between the entities or an entity
+type dialogflowPart struct {
+	Text        string `json:"text"`
+	UserDefined bool   `json:"userDefined"`
+}
+
+//This function gets the texts of a speech, that can be a string or a list of strings
+func (m dialogflowMessage) texts() []string {
+	var list []string
+	if err := json.Unmarshal(m.Speech, &list); err == nil {
+		return list
+	}
+	var text string
+	if err := json.Unmarshal(m.Speech, &text); err == nil && text != "" {
+		return []string{text}
+	}
+	return nil
+}
+
+//This function reads a Dialogflow agent export, from a directory with os.DirFS or from
+//the zip file. The fallback intent is read as noanswer
+func ReadDialogflow(fsys fs.FS) (*Dataset, error) {
+	//Some archives have the agent inside a directory
+	root := "."
+	if _, err := fs.Stat(fsys, "agent.json"); err != nil {
+		matches, _ := fs.Glob(fsys, "*/agent.json")
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("agent.json not found, it is not a Dialogflow agent")
+		}
+		root = path.Dir(matches[0])
+	}
+	var agent struct {
+		Language string `json:"language"`
+	}
+	if err := readJSON(fsys, path.Join(root, "agent.json"), &agent); err != nil {
+		return nil, err
+	}
+	files, err := fs.Glob(fsys, path.Join(root, "intents", "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	dataset := &Dataset{}
+	responses := make(map[string][]string)
+	for _, file := range files {
+		if strings.Contains(path.Base(file), "_usersays_") {
+			continue
+		}
+		var intent dialogflowIntent
+		if err := readJSON(fsys, file, &intent); err != nil {
+			return nil, err
+		}
+		name := intent.Name
+		if intent.FallbackIntent || name == DIALOGFLOW_FALLBACK {
+			name = "noanswer"
+		}
+		for _, response := range intent.Responses {
+			for _, message := range response.Messages {
+				if agent.Language == "" || message.Lang == "" || message.Lang == agent.Language {
+					responses[name] = append(responses[name], message.texts()...)
+				}
+			}
+		}
+		//The examples are on the file of the language of the agent, or any if it has none
+		says := strings.TrimSuffix(file, ".json") + "_usersays_" + agent.Language + ".json"
+		if agent.Language == "" {
+			matches, _ := fs.Glob(fsys, strings.TrimSuffix(file, ".json")+"_usersays_*.json")
+			if len(matches) == 0 {
+				continue
+			}
+			says = matches[0]
+		}
+		var examples []dialogflowExample
+		if err := readJSON(fsys, says, &examples); err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, example := range examples {
+			var text strings.Builder
+			for _, part := range example.Data {
+				text.WriteString(part.Text)
+			}
+			if sentence := strings.TrimSpace(text.String()); sentence != "" {
+				dataset.Examples = append(dataset.Examples, Example{Text: sentence, Category: name})
+			}
+		}
+	}
+	addResponses(dataset, responses)
+	return dataset, nil
+}
+
+//This function decodes a json file of a file system
+func readJSON(fsys fs.FS, name string, v interface{}) error {
+	content, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(bytes.TrimPrefix(content, []byte("\uFEFF")), v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+//Characters that can't be on the name of a file, or would take it to another directory
+var unsafeFileName = regexp.MustCompile(`[/\\:*?"<>|\x00-\x1f]`)
+
+//This function gets the name of the files of an intent, with the characters that can't be on a file name
+//replaced by "_". The intent keeps its real name inside the file
+func dialogflowFileName(name string) string {
+	return unsafeFileName.ReplaceAllString(name, "_")
+}
+
+//This function writes a dataset as a Dialogflow agent in the given language, on a directory or
+//on a zip file. noanswer is written as the fallback intent. Every intent is on a file named after it,
+//two intents whose names only differ on the characters a file name can't have are an error
+func WriteDialogflow(file string, lang string, dataset *Dataset) error {
+	files := make(map[string]interface{})
+	files["agent.json"] = map[string]interface{}{
+		"description":                "",
+		"language":                   lang,
+		"supportedLanguages":         []string{},
+		"enableOnePlatformResponses": true,
+	}
+	files["package.json"] = map[string]string{"version": "1.0.0"}
+	intents, examples, _ := groupExamples(dataset)
+	saved := make(map[string]string)
+	for _, name := range intents {
+		intent := dialogflowIntent{Name: name, Auto: true}
+		if name == "noanswer" {
+			intent.Name, intent.FallbackIntent = DIALOGFLOW_FALLBACK, true
+		}
+		base := "intents/" + dialogflowFileName(intent.Name)
+		if other, ok := saved[strings.ToLower(base)]; ok {
+			return fmt.Errorf("intents %q and %q would be saved on the same file %s.json", other, name, base)
+		}
+		saved[strings.ToLower(base)] = name
+		if texts := dataset.Responses[name]; len(texts) > 0 {
+			speech, err := json.Marshal(texts)
+			if err != nil {
+				return err
+			}
+			intent.Responses = []dialogflowResponse{{Messages: []dialogflowMessage{{Type: json.RawMessage(`"0"`), Lang: lang, Speech: speech}}}}
+		}
+		files[base+".json"] = intent
+		var says []dialogflowExample
+		for _, sentence := range examples[name] {
+			says = append(says, dialogflowExample{Data: []dialogflowPart{{Text: sentence}}, Lang: lang})
+		}
+		if len(says) > 0 {
+			files[base+"_usersays_"+dialogflowFileName(lang)+".json"] = says
+		}
+	}
+
+	//Write the files in order, on a zip or on the directory
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if strings.EqualFold(filepath.Ext(file), ".zip") {
+		return writeFile(file, func(f io.Writer) error {
+			archive := zip.NewWriter(f)
+			for _, name := range names {
+				content, err := json.MarshalIndent(files[name], "", "  ")
+				if err != nil {
+					return err
+				}
+				w, err := archive.Create(name)
+				if err == nil {
+					_, err = w.Write(content)
+				}
+				if err != nil {
+					return err
+				}
+			}
+			return archive.Close()
+		})
+	}
+	for _, name := range names {
+		content, err := json.MarshalIndent(files[name], "", "  ")
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(file, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(target, content, 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,260 @@
+package functions
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+//Examples of every kind a dataset can have, to save them and read them back
+var roundTripExamples = []Example{
+	{Text: "hola", Category: "greeting"},
+	{Text: "buenos días, ¿cómo estás?", Category: "greeting"},
+	{Text: `quiero "pizza" #1 con \ salsa`, Category: "food"},
+	{Text: "adios (amigo)", Category: "goodbye"},
+	{Text: "", Category: "noanswer"},
+	{Text: "ola", Category: "greeting", Generated: true, Source: "hola"},
+	{Text: "adio", Category: "goodbye", Generated: true},
+}
+
+//This function sorts the examples and forgets their lines, the formats keep them in different orders
+func sortedExamples(examples []Example) []Example {
+	sorted := append([]Example(nil), examples...)
+	for i := range sorted {
+		sorted[i].Line = 0
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		if a.Generated != b.Generated {
+			return b.Generated
+		}
+		return a.Text < b.Text
+	})
+	return sorted
+}
+
+func TestFormatPhrase(t *testing.T) {
+	tests := []struct {
+		example Example
+		want    string
+		err     bool
+	}{
+		{Example{Text: "hola", Category: "greeting"}, "#hola (greeting)", false},
+		{Example{Text: "  hola \n  amigo ", Category: "greeting"}, "#hola amigo (greeting)", false},
+		{Example{Text: `C# \o/`, Category: "lang"}, `#C\# \\o/ (lang)`, false},
+		{Example{Text: "", Category: "noanswer"}, "# (noanswer)", false},
+		{Example{Text: "ola", Category: "greeting", Generated: true, Source: "hola\namigo"}, "#ola (greeting) [augmented: hola amigo]", false},
+		{Example{Text: "ola", Category: "greeting", Generated: true}, "#ola (greeting) [augmented]", false},
+		{Example{Text: "hola", Category: "greeting (es)"}, "", true},
+		{Example{Text: "hola", Category: "#greeting"}, "", true},
+	}
+	for _, test := range tests {
+		got, err := FormatPhrase(test.example)
+		if (err != nil) != test.err || got != test.want {
+			t.Errorf("FormatPhrase(%+v) = %q, %v, want %q", test.example, got, err, test.want)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		//The line is read back as the same example
+		example, perr := parsePhrase(got)
+		if perr != nil {
+			t.Errorf("%q: %v", got, perr)
+			continue
+		}
+		want := test.example
+		want.Text, want.Source = strings.Join(strings.Fields(want.Text), " "), strings.Join(strings.Fields(want.Source), " ")
+		if *example != want {
+			t.Errorf("%q is read as %+v, want %+v", got, *example, want)
+		}
+	}
+}
+
+func TestDatasetRoundTrip(t *testing.T) {
+	responses := map[string][]string{"greeting": {"Hola!"}, "noanswer": {"No entiendo"}}
+	for _, ext := range []string{".txt", ".csv", ".jsonl", ".json", ".yaml"} {
+		file := filepath.Join(t.TempDir(), "dataset"+ext)
+		if err := SaveDataset(file, &Dataset{Examples: roundTripExamples, Responses: responses}); err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		dataset, err := LoadDataset(file)
+		if err != nil {
+			t.Fatalf("%s: %v", ext, err)
+		}
+		if got, want := sortedExamples(dataset.Examples), sortedExamples(roundTripExamples); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: read %+v, want %+v", ext, got, want)
+		}
+		//Only yaml keeps the responses with the examples
+		if ext == ".yaml" && !reflect.DeepEqual(dataset.Responses, responses) {
+			t.Errorf("%s: responses %v, want %v", ext, dataset.Responses, responses)
+		}
+	}
+	if err := SaveDataset(filepath.Join(t.TempDir(), "dataset.xml"), &Dataset{}); err == nil {
+		t.Error("SaveDataset of an unknown format didn't fail")
+	}
+}
+
+func TestNLURoundTrip(t *testing.T) {
+	dataset := &Dataset{Examples: roundTripExamples, Responses: map[string][]string{
+		"greeting": {"Hola!", "Buenas"}, "noanswer": {"No entiendo"}, "thanks": {"De nada"}}}
+	//The other tools have no generated examples, and no examples without a sentence
+	var want []Example
+	for _, example := range roundTripExamples {
+		if !example.Generated && example.Text != "" {
+			want = append(want, example)
+		}
+	}
+	tests := []struct {
+		file   string
+		format string
+		//The format keeps the responses
+		responses bool
+	}{
+		{"nlu.yml", RASA, true},
+		{"nlu.md", RASA_MD, false},
+		{"agent", DIALOGFLOW, true},
+		{"agent.zip", DIALOGFLOW, true},
+	}
+	for _, test := range tests {
+		file := filepath.Join(t.TempDir(), test.file)
+		empty, err := Export(file, test.format, "es", dataset)
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+		if empty != 1 {
+			t.Errorf("%s: left out %d examples without a sentence, want 1", test.file, empty)
+		}
+		if format, err := NLUFormat(file); err != nil || format != test.format {
+			t.Errorf("NLUFormat(%s) = %q, %v, want %q", test.file, format, err, test.format)
+		}
+		imported, err := Import(file, test.format)
+		if err != nil {
+			t.Fatalf("%s: %v", test.file, err)
+		}
+		var examples []Example
+		for _, example := range imported.Examples {
+			//The noanswer example only keeps the category of its responses
+			if example.Text != "" {
+				examples = append(examples, example)
+			}
+		}
+		if got := sortedExamples(examples); !reflect.DeepEqual(got, sortedExamples(want)) {
+			t.Errorf("%s: imported %+v, want %+v", test.file, got, sortedExamples(want))
+		}
+		if test.responses && !reflect.DeepEqual(imported.Responses, dataset.Responses) {
+			t.Errorf("%s: responses %v, want %v", test.file, imported.Responses, dataset.Responses)
+		}
+		//No temporary file is left next to the export
+		entries, _ := ioutil.ReadDir(filepath.Dir(file))
+		if len(entries) != 1 {
+			t.Errorf("%s: the export left %d files", test.file, len(entries))
+		}
+	}
+	if _, err := Export(filepath.Join(t.TempDir(), "nlu.json"), "luis", "es", dataset); err == nil {
+		t.Error("Export to an unknown format didn't fail")
+	}
+}
+
+func TestReadRasa(t *testing.T) {
+	content := `version: "3.1"
+nlu:
+- intent: order
+  examples: |
+    - quiero una [pizza](food)
+    - una [pizza]{"entity": "food", "value": "pizza"} para [Madrid](city)
+    -
+- synonym: pizza
+  examples: |
+    - piza
+responses:
+  utter_order:
+  - text: Marchando
+  - image: pizza.png
+  utter_default:
+  - text: Que?
+`
+	file := filepath.Join(t.TempDir(), "nlu.yml")
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	dataset, err := Import(file, RASA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//The entities keep only their text, and the synonyms are not examples
+	want := []Example{{Text: "quiero una pizza", Category: "order"}, {Text: "una pizza para Madrid", Category: "order"}}
+	if !reflect.DeepEqual(dataset.Examples, want) {
+		t.Errorf("examples %+v, want %+v", dataset.Examples, want)
+	}
+	if responses := map[string][]string{"order": {"Marchando"}, "default": {"Que?"}}; !reflect.DeepEqual(dataset.Responses, responses) {
+		t.Errorf("responses %v, want %v", dataset.Responses, responses)
+	}
+}
+
+func TestDialogflowFileName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"greeting", "greeting"},
+		{"Default Fallback Intent", "Default Fallback Intent"},
+		{"pedir.pizza", "pedir.pizza"},
+		{"../../evil", ".._.._evil"},
+		{`a\b:c*d?e"f<g>h|i`, "a_b_c_d_e_f_g_h_i"},
+		{"tab\there", "tab_here"},
+	}
+	for _, test := range tests {
+		if got := dialogflowFileName(test.name); got != test.want {
+			t.Errorf("dialogflowFileName(%q) = %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestWriteDialogflowCollisions(t *testing.T) {
+	tests := []struct {
+		intents []string
+		err     bool
+	}{
+		{[]string{"greeting", "goodbye"}, false},
+		{[]string{"../evil"}, false},
+		{[]string{"a/b", "a:b"}, true},
+		{[]string{"Greeting", "greeting"}, true},
+	}
+	for _, test := range tests {
+		dataset := &Dataset{}
+		for _, intent := range test.intents {
+			dataset.Examples = append(dataset.Examples, Example{Text: "hola", Category: intent})
+		}
+		dir := filepath.Join(t.TempDir(), "agent")
+		err := WriteDialogflow(dir, "es", dataset)
+		if (err != nil) != test.err {
+			t.Errorf("%v: error = %v, want error %t", test.intents, err, test.err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		//Every file stays inside the agent, and the intents keep their names
+		imported, err := Import(dir, DIALOGFLOW)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, example := range imported.Examples {
+			got = append(got, example.Category)
+		}
+		sort.Strings(got)
+		want := append([]string(nil), test.intents...)
+		sort.Strings(want)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: imported the intents %v", test.intents, got)
+		}
+	}
+}
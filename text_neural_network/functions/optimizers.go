@@ -0,0 +1,196 @@
+package functions
+
+import (
+	"fmt"
+	"math"
+
+	"gonum.org/v1/gonum/blas/blas64"
+	"gonum.org/v1/gonum/mat"
+)
+
+//Names of the optimizers and learning rate schedules, as saved on the model file
+const (
+	SGD      = "sgd"
+	MOMENTUM = "momentum"
+	RMSPROP  = "rmsprop"
+	ADAM     = "adam"
+
+	CONSTANT    = "constant"
+	STEP        = "step"
+	EXPONENTIAL = "exponential"
+	COSINE      = "cosine"
+)
+
+//Optimizer changes the weights of the network with the gradients of every batch
+type Optimizer interface {
+	//Update moves every weights matrix against its gradient, rate is the learning rate
+	Update(weights []*mat.Dense, gradients []*mat.Dense, rate float64)
+	//State gets everything the optimizer needs to continue, to save it on the model
+	State() OptimizerState
+}
+
+//OptimizerState is an optimizer as saved on the model file
+type OptimizerState struct {
+	Name string
+	//Decay of the moving averages: Beta1 for the momentum and Adam first moment,
+	//Beta2 for the RMSProp and Adam squared gradients
+	Beta1   float64 `json:",omitempty"`
+	Beta2   float64 `json:",omitempty"`
+	Epsilon float64 `json:",omitempty"`
+	//Number of updates done, and the moving averages of every weights matrix
+	Steps  int              `json:",omitempty"`
+	First  []blas64.General `json:",omitempty"`
+	Second []blas64.General `json:",omitempty"`
+}
+
+//This function gets a new optimizer with its default parameters
+func NewOptimizer(name string) (Optimizer, error) {
+	switch name {
+	case SGD, "":
+		return RestoreOptimizer(OptimizerState{Name: SGD})
+	case MOMENTUM:
+		return RestoreOptimizer(OptimizerState{Name: MOMENTUM, Beta1: 0.9})
+	case RMSPROP:
+		return RestoreOptimizer(OptimizerState{Name: RMSPROP, Beta2: 0.9, Epsilon: 1e-8})
+	case ADAM:
+		return RestoreOptimizer(OptimizerState{Name: ADAM, Beta1: 0.9, Beta2: 0.999, Epsilon: 1e-8})
+	}
+	return nil, fmt.Errorf("unknown optimizer %q", name)
+}
+
+//This function gets an optimizer back from its saved state, to continue a training
+func RestoreOptimizer(state OptimizerState) (Optimizer, error) {
+	o := &optimizer{OptimizerState: state}
+	for _, m := range state.First {
+		o.first = append(o.first, mat.NewDense(m.Rows, m.Cols, m.Data))
+	}
+	for _, m := range state.Second {
+		o.second = append(o.second, mat.NewDense(m.Rows, m.Cols, m.Data))
+	}
+	switch state.Name {
+	case SGD, MOMENTUM, RMSPROP, ADAM:
+		return o, nil
+	}
+	return nil, fmt.Errorf("unknown optimizer %q", state.Name)
+}
+
+//optimizer implements all of the optimizers, they only differ on the moving averages they keep
+type optimizer struct {
+	OptimizerState
+	first  []*mat.Dense
+	second []*mat.Dense
+}
+
+func (o *optimizer) Update(weights []*mat.Dense, gradients []*mat.Dense, rate float64) {
+	//The moving averages start at zero, with the same size as the weights
+	if o.first == nil && (o.Name == MOMENTUM || o.Name == ADAM) {
+		o.first = zerosLike(weights)
+	}
+	if o.second == nil && (o.Name == RMSPROP || o.Name == ADAM) {
+		o.second = zerosLike(weights)
+	}
+	o.Steps++
+	for k, w := range weights {
+		g := gradients[k].RawMatrix()
+		data := w.RawMatrix()
+		for i := 0; i < data.Rows; i++ {
+			for j := 0; j < data.Cols; j++ {
+				grad := g.Data[i*g.Stride+j]
+				data.Data[i*data.Stride+j] -= o.step(k, i, j, grad, rate)
+			}
+		}
+	}
+}
+
+//This function gets how much to move a single weight, given its gradient
+func (o *optimizer) step(k, i, j int, grad float64, rate float64) float64 {
+	switch o.Name {
+	case MOMENTUM:
+		//Keep moving on the direction of the previous updates
+		v := o.Beta1*o.first[k].At(i, j) + rate*grad
+		o.first[k].Set(i, j, v)
+		return v
+	case RMSPROP:
+		//Divide by the moving average of the squared gradients
+		s := o.Beta2*o.second[k].At(i, j) + (1-o.Beta2)*grad*grad
+		o.second[k].Set(i, j, s)
+		return rate * grad / (math.Sqrt(s) + o.Epsilon)
+	case ADAM:
+		//Moving averages of the gradients and the squared gradients, corrected for starting at zero
+		m := o.Beta1*o.first[k].At(i, j) + (1-o.Beta1)*grad
+		v := o.Beta2*o.second[k].At(i, j) + (1-o.Beta2)*grad*grad
+		o.first[k].Set(i, j, m)
+		o.second[k].Set(i, j, v)
+		m_hat := m / (1 - math.Pow(o.Beta1, float64(o.Steps)))
+		v_hat := v / (1 - math.Pow(o.Beta2, float64(o.Steps)))
+		return rate * m_hat / (math.Sqrt(v_hat) + o.Epsilon)
+	}
+	return rate * grad
+}
+
+func (o *optimizer) State() OptimizerState {
+	state := o.OptimizerState
+	state.First, state.Second = nil, nil
+	for _, m := range o.first {
+		state.First = append(state.First, mat.DenseCopyOf(m).RawMatrix())
+	}
+	for _, m := range o.second {
+		state.Second = append(state.Second, mat.DenseCopyOf(m).RawMatrix())
+	}
+	return state
+}
+
+//This function gets a zero matrix for every matrix, with the same dimensions
+func zerosLike(matrixes []*mat.Dense) []*mat.Dense {
+	var zeros []*mat.Dense
+	for _, m := range matrixes {
+		r, c := m.Dims()
+		zeros = append(zeros, mat.NewDense(r, c, nil))
+	}
+	return zeros
+}
+
+//Schedule changes the learning rate along the training
+type Schedule struct {
+	//One of constant, step, exponential or cosine
+	Name string
+	//The step and exponential schedules multiply the rate by Decay every Step epochs,
+	//step at once and exponential a little on every epoch
+	Decay float64 `json:",omitempty"`
+	Step  int     `json:",omitempty"`
+	//The cosine schedule goes from the initial rate down to Min over Epochs epochs
+	Min    float64 `json:",omitempty"`
+	Epochs int     `json:",omitempty"`
+}
+
+//This function checks the schedule has everything it needs
+func (s Schedule) Validate() error {
+	switch s.Name {
+	case CONSTANT, "":
+	case STEP, EXPONENTIAL:
+		if s.Step <= 0 || s.Decay <= 0 {
+			return fmt.Errorf("the %s schedule needs a positive step and decay", s.Name)
+		}
+	case COSINE:
+		if s.Epochs <= 0 {
+			return fmt.Errorf("the cosine schedule needs a positive number of epochs")
+		}
+	default:
+		return fmt.Errorf("unknown learning rate schedule %q", s.Name)
+	}
+	return nil
+}
+
+//This function gets the learning rate for an epoch, starting from alpha
+func (s Schedule) Rate(alpha float64, epoch int) float64 {
+	switch s.Name {
+	case STEP:
+		return alpha * math.Pow(s.Decay, float64(epoch/s.Step))
+	case EXPONENTIAL:
+		return alpha * math.Pow(s.Decay, float64(epoch)/float64(s.Step))
+	case COSINE:
+		progress := math.Min(float64(epoch)/float64(s.Epochs), 1)
+		return s.Min + (alpha-s.Min)*(1+math.Cos(math.Pi*progress))/2
+	}
+	return alpha
+}
@@ -0,0 +1,149 @@
+package functions
+
+import (
+	"math"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestOptimizerSteps(t *testing.T) {
+	//Two updates of the weights [1, -2] with the same gradient [0.5, -1] and learning rate 0.1
+	tests := []struct {
+		name string
+		//Weights after the first and the second update
+		first, second []float64
+	}{
+		{SGD, []float64{0.95, -1.9}, []float64{0.9, -1.8}},
+		//The second step adds 0.9 of the first one
+		{MOMENTUM, []float64{0.95, -1.9}, []float64{0.855, -1.71}},
+		//The gradient over the root of the moving average of its square, 0.1 and then 0.19 of it
+		{RMSPROP, []float64{1 - 0.05/math.Sqrt(0.025), -2 + 0.1/math.Sqrt(0.1)},
+			[]float64{1 - 0.05/math.Sqrt(0.025) - 0.05/math.Sqrt(0.0475), -2 + 0.1/math.Sqrt(0.1) + 0.1/math.Sqrt(0.19)}},
+		//With a constant gradient the corrected moments make every step the learning rate
+		{ADAM, []float64{0.9, -1.9}, []float64{0.8, -1.8}},
+	}
+	for _, test := range tests {
+		optimizer, err := NewOptimizer(test.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		weights := []*mat.Dense{mat.NewDense(1, 2, []float64{1, -2})}
+		gradients := []*mat.Dense{mat.NewDense(1, 2, []float64{0.5, -1})}
+		for step, want := range [][]float64{test.first, test.second} {
+			optimizer.Update(weights, gradients, 0.1)
+			got := weights[0].RawRowView(0)
+			for j := range want {
+				if math.Abs(got[j]-want[j]) > 1e-6 {
+					t.Errorf("%s: weights after step %d = %v, want %v", test.name, step+1, got, want)
+					break
+				}
+			}
+		}
+		if steps := optimizer.State().Steps; steps != 2 {
+			t.Errorf("%s: %d steps saved, want 2", test.name, steps)
+		}
+	}
+}
+
+func TestRestoreOptimizer(t *testing.T) {
+	for _, name := range []string{SGD, MOMENTUM, RMSPROP, ADAM} {
+		gradients := []*mat.Dense{mat.NewDense(2, 2, []float64{0.3, -0.2, 0.1, 0.7})}
+		weights := []*mat.Dense{mat.NewDense(2, 2, []float64{1, 2, 3, 4})}
+		optimizer, err := NewOptimizer(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		optimizer.Update(weights, gradients, 0.05)
+		//An optimizer restored from its state continues just like the one that was saved
+		restored, err := RestoreOptimizer(optimizer.State())
+		if err != nil {
+			t.Fatal(err)
+		}
+		copied := []*mat.Dense{mat.DenseCopyOf(weights[0])}
+		optimizer.Update(weights, gradients, 0.05)
+		restored.Update(copied, gradients, 0.05)
+		if !mat.Equal(weights[0], copied[0]) {
+			t.Errorf("%s: restored optimizer moved the weights to %v, want %v", name, mat.Formatted(copied[0]), mat.Formatted(weights[0]))
+		}
+	}
+	if _, err := NewOptimizer("adagrad"); err == nil {
+		t.Error("NewOptimizer(adagrad) didn't fail")
+	}
+	if _, err := RestoreOptimizer(OptimizerState{Name: "adagrad"}); err == nil {
+		t.Error("RestoreOptimizer(adagrad) didn't fail")
+	}
+}
+
+func TestScheduleRate(t *testing.T) {
+	tests := []struct {
+		schedule Schedule
+		epoch    int
+		want     float64
+	}{
+		{Schedule{}, 500, 1},
+		{Schedule{Name: CONSTANT}, 500, 1},
+		{Schedule{Name: STEP, Decay: 0.5, Step: 10}, 9, 1},
+		{Schedule{Name: STEP, Decay: 0.5, Step: 10}, 10, 0.5},
+		{Schedule{Name: STEP, Decay: 0.5, Step: 10}, 25, 0.25},
+		{Schedule{Name: EXPONENTIAL, Decay: 0.5, Step: 10}, 5, math.Sqrt(0.5)},
+		{Schedule{Name: EXPONENTIAL, Decay: 0.5, Step: 10}, 20, 0.25},
+		{Schedule{Name: COSINE, Min: 0.1, Epochs: 100}, 0, 1},
+		{Schedule{Name: COSINE, Min: 0.1, Epochs: 100}, 50, 0.55},
+		{Schedule{Name: COSINE, Min: 0.1, Epochs: 100}, 100, 0.1},
+		//After its epochs the cosine schedule stays at the minimum
+		{Schedule{Name: COSINE, Min: 0.1, Epochs: 100}, 200, 0.1},
+	}
+	for _, test := range tests {
+		if got := test.schedule.Rate(1, test.epoch); math.Abs(got-test.want) > 1e-12 {
+			t.Errorf("%+v: rate at epoch %d = %v, want %v", test.schedule, test.epoch, got, test.want)
+		}
+	}
+}
+
+func TestScheduleValidate(t *testing.T) {
+	tests := []struct {
+		schedule Schedule
+		err      bool
+	}{
+		{Schedule{}, false},
+		{Schedule{Name: CONSTANT}, false},
+		{Schedule{Name: STEP, Decay: 0.5, Step: 10}, false},
+		{Schedule{Name: STEP, Decay: 0.5}, true},
+		{Schedule{Name: EXPONENTIAL, Step: 10}, true},
+		{Schedule{Name: COSINE, Epochs: 10}, false},
+		{Schedule{Name: COSINE}, true},
+		{Schedule{Name: "linear"}, true},
+	}
+	for _, test := range tests {
+		if err := test.schedule.Validate(); (err != nil) != test.err {
+			t.Errorf("%+v: Validate() = %v, want error %t", test.schedule, err, test.err)
+		}
+	}
+}
+
+func TestTrainOptimizers(t *testing.T) {
+	db, x, y, words, categories := toyData()
+	tests := []struct {
+		optimizer string
+		alpha     float64
+		schedule  Schedule
+	}{
+		{SGD, 0.5, Schedule{}},
+		{MOMENTUM, 0.1, Schedule{Name: STEP, Decay: 0.5, Step: 200}},
+		{RMSPROP, 0.01, Schedule{Name: EXPONENTIAL, Decay: 0.5, Step: 200}},
+		{ADAM, 0.01, Schedule{Name: COSINE}},
+	}
+	for _, test := range tests {
+		config := toyConfig()
+		config.Optimizer, config.Alpha, config.Schedule = test.optimizer, test.alpha, test.schedule
+		model, err := Train(x, y, config, words, categories)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if model.Optimizer.Name != test.optimizer {
+			t.Errorf("%s: the model saves the optimizer %q", test.optimizer, model.Optimizer.Name)
+		}
+		checkLearned(t, model, db)
+	}
+}
@@ -0,0 +1,342 @@
+package functions
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+//Names of the steps that turn a sentence into words
+const (
+	NFKC         = "nfkc"
+	LOWERCASE    = "lowercase"
+	PUNCTUATION  = "punctuation"
+	NUMBERS      = "numbers"
+	EMOJI        = "emoji"
+	STOPWORDS    = "stopwords"
+	LEMMAS       = "lemmas"
+	STEM         = "stem"
+	FOLD_ACCENTS = "fold_accents"
+)
+
+//The word every number is read as by the numbers step
+const NUMBER_WORD = "<num>"
+
+//Articles and unnecesary words, they say nothing about the category of a sentence.
+//The stopwords step drops them when the model has no list of its own
+var stopwords = []string{"la", "a", "un", "una", "?", "!", "el", "con", "sin", "en", "para",
+	"por", ".", "siempre", "desde", "los", "las", "me", "que", "tan", "de", "favor"}
+
+//Normalizer is a step of the preprocessing, it turns the words of a sentence into other words.
+//It can change every word, drop some of them or split them in more words
+type Normalizer interface {
+	Normalize(words []string) []string
+}
+
+//NormalizerFunc is a function used as a Normalizer
+type NormalizerFunc func(words []string) []string
+
+func (f NormalizerFunc) Normalize(words []string) []string {
+	return f(words)
+}
+
+//This function gets a Normalizer that changes every word on its own, dropping the ones that end empty
+func EachWord(change func(word string) string) Normalizer {
+	return NormalizerFunc(func(words []string) []string {
+		var output []string
+		for _, word := range words {
+			if word = change(word); word != "" {
+				output = append(output, word)
+			}
+		}
+		return output
+	})
+}
+
+//The normalizer of every step, built with the data of the preprocessing. More can be added with RegisterNormalizer
+var normalizers = map[string]func(p Preprocessing) Normalizer{
+	NFKC:        func(Preprocessing) Normalizer { return EachWord(norm.NFKC.String) },
+	LOWERCASE:   func(Preprocessing) Normalizer { return EachWord(strings.ToLower) },
+	PUNCTUATION: func(Preprocessing) Normalizer { return NormalizerFunc(splitPunctuation) },
+	NUMBERS:     func(Preprocessing) Normalizer { return EachWord(number) },
+	EMOJI:       func(Preprocessing) Normalizer { return NormalizerFunc(splitEmoji) },
+	STOPWORDS:   func(p Preprocessing) Normalizer { return dropStopwords(p.Stopwords) },
+	LEMMAS: func(p Preprocessing) Normalizer {
+		return EachWord(func(word string) string {
+			return mapLetters(word, func(letters string) string {
+				if lemma, ok := p.Lemmas[letters]; ok {
+					return lemma
+				}
+				return letters
+			})
+		})
+	},
+	STEM: func(Preprocessing) Normalizer {
+		return EachWord(func(word string) string { return mapLetters(word, StemSpanish) })
+	},
+	FOLD_ACCENTS: func(Preprocessing) Normalizer { return EachWord(foldAccents) },
+}
+
+//This function sets the normalizer of a step, the preprocessing of a model can use it by its name.
+//A model saved with the step can only be loaded where it is registered
+func RegisterNormalizer(name string, build func(p Preprocessing) Normalizer) {
+	normalizers[name] = build
+}
+
+//Preprocessing is the list of steps, in order, that turn a sentence into the words of the vocabulary,
+//with the lemma of every word the lemmas step knows and the words the stopwords step drops.
+//A model only works with the same steps it was trained with, so it is saved with them
+type Preprocessing struct {
+	Steps  []string
+	Lemmas map[string]string `json:",omitempty"`
+	//The stopwords of the language of the model, the default spanish ones if it has none
+	Stopwords []string `json:",omitempty"`
+}
+
+//This function gets the preprocessing done by scanWords: lowercase, drop the stopwords and remove accents
+func DefaultPreprocessing() Preprocessing {
+	return Preprocessing{Steps: []string{LOWERCASE, STOPWORDS, FOLD_ACCENTS}}
+}
+
+//This function reads a list of steps separated by commas, like "nfkc,lowercase,punctuation,fold_accents"
+func ParseSteps(spec string) ([]string, error) {
+	var steps []string
+	for _, step := range strings.Split(spec, ",") {
+		step = strings.ToLower(strings.TrimSpace(step))
+		if step == "" {
+			continue
+		}
+		if _, ok := normalizers[step]; !ok {
+			return nil, fmt.Errorf("unknown preprocessing step %q, it must be one of %s", step, strings.Join(stepNames(), " "))
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+//This function gets the name of every step, in alphabetical order
+func stepNames() []string {
+	var names []string
+	for name := range normalizers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//This function gets a preprocessing with the given steps. With lemmas, every word is read as its lemma,
+//and with stem it is cut to its spanish stem. When the steps don't have them, both are added before
+//removing the accents, the stemmer needs them and the lemmas are written with them
+func NewPreprocessing(steps []string, stem bool, lemmas map[string]string, stopword_list []string) Preprocessing {
+	p := Preprocessing{Lemmas: lemmas, Stopwords: stopword_list}
+	var extra []string
+	if found, _ := Find(steps, LEMMAS); !found && len(lemmas) > 0 {
+		extra = append(extra, LEMMAS)
+	}
+	if found, _ := Find(steps, STEM); !found && stem {
+		extra = append(extra, STEM)
+	}
+	found, i := Find(steps, FOLD_ACCENTS)
+	if !found {
+		i = len(steps)
+	}
+	p.Steps = append(append(append([]string(nil), steps[:i]...), extra...), steps[i:]...)
+	return p
+}
+
+//This function reads a file of lemmas, every line a lemma followed by the words that are read as it:
+//
+//	//Comments and blank lines are ignored
+//	querer quiero quieres quisiera
+//	gustar gusta gustaría
+//
+//A word is also found without its accents, like "gustaria"
+func LoadLemmas(file string) (map[string]string, error) {
+	lemmas := make(map[string]string)
+	err := scanList(file, func(number int, line string) error {
+		fields := strings.Fields(strings.ToLower(line))
+		if len(fields) < 2 {
+			return &ParseError{File: file, Line: number, Column: 1, Text: line, Msg: "expected a lemma and the words that are read as it"}
+		}
+		for _, word := range fields {
+			lemmas[word] = fields[0]
+			if folded := foldAccents(word); lemmas[folded] == "" {
+				lemmas[folded] = fields[0]
+			}
+		}
+		return nil
+	})
+	return lemmas, err
+}
+
+//This function reads a file of stopwords of a language, the words on every line separated by spaces:
+//
+//	//Comments and blank lines are ignored
+//	la el los las
+//	un una
+func LoadStopwords(file string) ([]string, error) {
+	var words []string
+	err := scanList(file, func(number int, line string) error {
+		words = append(words, strings.Fields(strings.ToLower(line))...)
+		return nil
+	})
+	return words, err
+}
+
+//This function goes through the lines of a list file that are not blank or comments
+func scanList(file string, line func(number int, text string) error) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for number := 1; scanner.Scan(); number++ {
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\uFEFF"))
+		if text == "" || strings.HasPrefix(text, "//") {
+			continue
+		}
+		if err := line(number, text); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+//This function checks every step of the preprocessing is known
+func (p Preprocessing) validate() error {
+	for _, step := range p.Steps {
+		if _, ok := normalizers[step]; !ok {
+			return fmt.Errorf("unknown preprocessing step %q", step)
+		}
+	}
+	return nil
+}
+
+//pipeline is the normalizer of every step of a preprocessing, in order. Building them makes the
+//maps of the lemmas and stopwords, so it is built once and every sentence is read with it
+type pipeline []Normalizer
+
+//This function builds the normalizer of every step of the preprocessing
+func (p Preprocessing) build() pipeline {
+	var steps pipeline
+	for _, step := range p.Steps {
+		steps = append(steps, normalizers[step](p))
+	}
+	return steps
+}
+
+//This function turns the words of a sentence into words of the vocabulary, going through every step in order
+func (steps pipeline) words(words []string) []string {
+	for _, step := range steps {
+		words = step.Normalize(words)
+	}
+	return words
+}
+
+//This function gets a normalizer that drops the stopwords, lowercase or not
+func dropStopwords(list []string) Normalizer {
+	if len(list) == 0 {
+		list = stopwords
+	}
+	drop := make(map[string]bool)
+	for _, word := range list {
+		drop[word] = true
+	}
+	return EachWord(func(word string) string {
+		//We find if the word is inside ignored database
+		if drop[strings.ToLower(word)] {
+			return ""
+		}
+		return word
+	})
+}
+
+//This function splits the words at their punctuation, so "hola!" is "hola" and "coca-cola" is "coca" and "cola"
+func splitPunctuation(words []string) []string {
+	var output []string
+	for _, word := range words {
+		output = append(output, strings.FieldsFunc(word, unicode.IsPunct)...)
+	}
+	return output
+}
+
+//This function reads a number as NUMBER_WORD, so every quantity is the same word
+func number(word string) string {
+	digits := false
+	for _, r := range word {
+		switch {
+		case unicode.IsDigit(r):
+			digits = true
+		case r != '.' && r != ',':
+			return word
+		}
+	}
+	if digits {
+		return NUMBER_WORD
+	}
+	return word
+}
+
+//This function gets if a character is an emoji, or one of the pictographs and symbols used like them
+func isEmoji(r rune) bool {
+	return unicode.Is(unicode.So, r) || (r >= 0x1F000 && r <= 0x1FAFF)
+}
+
+//This function gets if a character only changes the emoji before it: its color, its skin tone,
+//or the joiner of the emojis made of others
+func isEmojiModifier(r rune) bool {
+	return r == 0xFE0F || r == 0xFE0E || r == 0x200D || (r >= 0x1F3FB && r <= 0x1F3FF)
+}
+
+//This function splits the emojis from the words, every emoji is a word of its own, without its skin tone or color
+func splitEmoji(words []string) []string {
+	var output []string
+	for _, word := range words {
+		var current strings.Builder
+		flush := func() {
+			if current.Len() > 0 {
+				output = append(output, current.String())
+				current.Reset()
+			}
+		}
+		for _, r := range word {
+			switch {
+			case isEmojiModifier(r):
+			case isEmoji(r):
+				flush()
+				output = append(output, string(r))
+			default:
+				current.WriteRune(r)
+			}
+		}
+		flush()
+	}
+	return output
+}
+
+//This function changes the letters of a word, keeping the punctuation around them like in "pizzas?"
+func mapLetters(text string, change func(string) string) string {
+	start := strings.IndexFunc(text, unicode.IsLetter)
+	end := strings.LastIndexFunc(text, unicode.IsLetter)
+	if start < 0 {
+		return text
+	}
+	_, size := utf8.DecodeRuneInString(text[end:])
+	end += size
+	return text[:start] + change(text[start:end]) + text[end:]
+}
+
+//This function removes the accents of a word
+func foldAccents(text string) string {
+	t := transform.Chain(norm.NFD, transform.RemoveFunc(isMn), norm.NFC)
+	result, _, _ := transform.String(t, text)
+	return result
+}
@@ -0,0 +1,135 @@
+package functions
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSteps(t *testing.T) {
+	tests := []struct {
+		spec string
+		want []string
+		err  bool
+	}{
+		{"nfkc,lowercase,punctuation,fold_accents", []string{NFKC, LOWERCASE, PUNCTUATION, FOLD_ACCENTS}, false},
+		{" Lowercase , NUMBERS,,emoji ", []string{LOWERCASE, NUMBERS, EMOJI}, false},
+		{"", nil, false},
+		{"lowercase,stemming", nil, true},
+	}
+	for _, test := range tests {
+		got, err := ParseSteps(test.spec)
+		if (err != nil) != test.err || !reflect.DeepEqual(got, test.want) {
+			t.Errorf("ParseSteps(%q) = %q, %v, want %q", test.spec, got, err, test.want)
+		}
+	}
+}
+
+func TestNewPreprocessing(t *testing.T) {
+	lemmas := map[string]string{"quiero": "querer"}
+	tests := []struct {
+		steps  []string
+		stem   bool
+		lemmas map[string]string
+		want   []string
+	}{
+		{[]string{LOWERCASE, FOLD_ACCENTS}, false, nil, []string{LOWERCASE, FOLD_ACCENTS}},
+		//The lemmas and the stemmer go before removing the accents
+		{[]string{LOWERCASE, STOPWORDS, FOLD_ACCENTS}, true, lemmas, []string{LOWERCASE, STOPWORDS, LEMMAS, STEM, FOLD_ACCENTS}},
+		{[]string{LOWERCASE}, true, nil, []string{LOWERCASE, STEM}},
+		//Steps already on the list stay where they are
+		{[]string{STEM, LOWERCASE, FOLD_ACCENTS}, true, lemmas, []string{STEM, LOWERCASE, LEMMAS, FOLD_ACCENTS}},
+		{[]string{LOWERCASE, LEMMAS}, false, nil, []string{LOWERCASE, LEMMAS}},
+	}
+	for _, test := range tests {
+		p := NewPreprocessing(test.steps, test.stem, test.lemmas, nil)
+		if !reflect.DeepEqual(p.Steps, test.want) {
+			t.Errorf("NewPreprocessing(%q, %t) steps %q, want %q", test.steps, test.stem, p.Steps, test.want)
+		}
+	}
+}
+
+func TestNormalizers(t *testing.T) {
+	tests := []struct {
+		step     string
+		sentence string
+		want     []string
+	}{
+		{NFKC, "ｈｏｌａ ﬁn", []string{"hola", "fin"}},
+		{LOWERCASE, "Hola AMIGO", []string{"hola", "amigo"}},
+		{PUNCTUATION, "¡hola! coca-cola ... ¿qué?", []string{"hola", "coca", "cola", "qué"}},
+		{NUMBERS, "quiero 2 pizzas de 3,50 o 1.000 v2 ...", []string{"quiero", NUMBER_WORD, "pizzas", "de", NUMBER_WORD, "o", NUMBER_WORD, "v2", "..."}},
+		//Every emoji is a word, without its skin tone
+		{EMOJI, "hola👍🏽amigo ❤️ 👨‍👩‍👧", []string{"hola", "👍", "amigo", "❤", "👨", "👩", "👧"}},
+		{STOPWORDS, "La pizza con queso", []string{"pizza", "queso"}},
+		{FOLD_ACCENTS, "qué días ñandú", []string{"que", "dias", "nandu"}},
+	}
+	for _, test := range tests {
+		p := Preprocessing{Steps: []string{test.step}}
+		if got := scanWords(test.sentence, p.build()); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: words of %q = %q, want %q", test.step, test.sentence, got, test.want)
+		}
+	}
+}
+
+func TestStopwords(t *testing.T) {
+	dir := t.TempDir()
+	file := writeTestFile(t, dir, "stopwords.txt", "//Articles\nThe a an\n\nof  to\n")
+	list, err := LoadStopwords(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"the", "a", "an", "of", "to"}; !reflect.DeepEqual(list, want) {
+		t.Errorf("stopwords %q, want %q", list, want)
+	}
+	if _, err := LoadStopwords(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("LoadStopwords of a missing file didn't fail")
+	}
+	tests := []struct {
+		list []string
+		want []string
+	}{
+		//Without a list of its own the model drops the spanish stopwords, in any case
+		{nil, []string{"The", "pizza", "of", "the", "day"}},
+		{list, []string{"pizza", "day", "la"}},
+	}
+	for _, test := range tests {
+		p := NewPreprocessing([]string{STOPWORDS}, false, nil, test.list)
+		if got := scanWords("The pizza of the day la", p.build()); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("stopwords %q: words %q, want %q", test.list, got, test.want)
+		}
+	}
+}
+
+func TestRegisterNormalizer(t *testing.T) {
+	builds := 0
+	RegisterNormalizer("upper", func(Preprocessing) Normalizer {
+		builds++
+		return EachWord(strings.ToUpper)
+	})
+	defer delete(normalizers, "upper")
+	steps, err := ParseSteps("lowercase,upper")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := Preprocessing{Steps: steps}
+	if err := p.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Preprocessing{Steps: []string{"lower"}}).validate(); err == nil {
+		t.Error("a preprocessing with an unknown step is valid")
+	}
+	//The model builds its pipeline once, for every sentence it reads
+	model := keywordModel()
+	model.Preprocessing = p
+	for _, sentence := range []string{"hola", "Adios amigo", "pizza"} {
+		model.Predict(sentence, false)
+	}
+	if got := scanWords("Hola amigo", model.pipeline()); !reflect.DeepEqual(got, []string{"HOLA", "AMIGO"}) {
+		t.Errorf("words %q", got)
+	}
+	if builds != 1 {
+		t.Errorf("the pipeline was built %d times, want once", builds)
+	}
+}
@@ -0,0 +1,343 @@
+package functions
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+//Dataset is what a dataset file has: the examples to train with, and the responses
+//of every category when the format keeps them with the examples
+type Dataset struct {
+	Examples  []Example
+	Responses map[string][]string
+}
+
+//DatasetReader reads a dataset in one format. The name is the one the errors use for the file,
+//every malformed example is a *ParseError, all of them are returned together as ParseErrors
+type DatasetReader interface {
+	Read(r io.Reader, name string) (*Dataset, error)
+}
+
+//The readers of every extension, more can be added with RegisterReader
+var readers = map[string]DatasetReader{
+	".txt":   phrasesReader{},
+	".csv":   csvReader{},
+	".jsonl": jsonlReader{},
+	".json":  jsonReader{},
+	".yaml":  yamlReader{},
+	".yml":   yamlReader{},
+}
+
+//This function sets the reader of the dataset files with an extension, like ".tsv"
+func RegisterReader(ext string, reader DatasetReader) {
+	readers[strings.ToLower(ext)] = reader
+}
+
+//This function gets the reader of a dataset file from its extension
+func ReaderFor(file string) (DatasetReader, error) {
+	ext := strings.ToLower(filepath.Ext(file))
+	reader, ok := readers[ext]
+	if !ok {
+		var known []string
+		for k := range readers {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("unknown dataset format %q of %s, it must be one of %s", ext, file, strings.Join(known, " "))
+	}
+	return reader, nil
+}
+
+//This function reads a dataset file with the reader of its extension
+func LoadDataset(file string) (*Dataset, error) {
+	reader, err := ReaderFor(file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return reader.Read(f, file)
+}
+
+//phrasesReader reads the "#sentence (category)" lines of chatss.txt
+type phrasesReader struct{}
+
+func (phrasesReader) Read(r io.Reader, name string) (*Dataset, error) {
+	examples, err := ParsePhrases(r, name)
+	return &Dataset{Examples: examples}, err
+}
+
+//record is an example on the json, jsonl and csv formats
+type record struct {
+	Text      string `json:"text"`
+	Intent    string `json:"intent"`
+	Augmented bool   `json:"augmented,omitempty"`
+	Source    string `json:"source,omitempty"`
+}
+
+//This function gets the record of an example
+func newRecord(example Example) record {
+	return record{Text: example.Text, Intent: example.Category, Augmented: example.Generated, Source: example.Source}
+}
+
+//This function checks a record has both its text and its intent
+func (rec record) example(name string, line int, column int) (Example, *ParseError) {
+	rec.Text, rec.Intent = strings.TrimSpace(rec.Text), strings.TrimSpace(rec.Intent)
+	switch {
+	case rec.Intent == "":
+		return Example{}, &ParseError{File: name, Line: line, Column: column, Msg: "missing intent"}
+	case rec.Text == "" && rec.Intent != "noanswer":
+		return Example{}, &ParseError{File: name, Line: line, Column: column, Msg: "missing text"}
+	}
+	return Example{Text: rec.Text, Category: rec.Intent, Line: line, Generated: rec.Augmented, Source: strings.TrimSpace(rec.Source)}, nil
+}
+
+//This function gets the result of a reader: the examples, and the errors if there were any
+func readResult(dataset *Dataset, errs ParseErrors) (*Dataset, error) {
+	if len(errs) > 0 {
+		return dataset, errs
+	}
+	return dataset, nil
+}
+
+//csvReader reads a text,intent row for every example, the first row can be that header.
+//A third augmented column tells the generated examples with true, and a fourth one the sentence they were made from
+type csvReader struct{}
+
+func (csvReader) Read(r io.Reader, name string) (*Dataset, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	dataset := &Dataset{}
+	var errs ParseErrors
+	for row := 0; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if perr, ok := err.(*csv.ParseError); ok {
+			errs = append(errs, &ParseError{File: name, Line: perr.Line, Column: perr.Column, Msg: perr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return dataset, err
+		}
+		line, column := reader.FieldPos(0)
+		//Spreadsheets save the byte order mark at the start
+		if row == 0 {
+			fields[0] = strings.TrimPrefix(fields[0], "\uFEFF")
+			if len(fields) >= 2 && strings.EqualFold(strings.TrimSpace(fields[0]), "text") && strings.EqualFold(strings.TrimSpace(fields[1]), "intent") {
+				continue
+			}
+		}
+		if len(fields) < 2 || len(fields) > 4 {
+			errs = append(errs, &ParseError{File: name, Line: line, Column: column, Msg: fmt.Sprintf("expected 2 fields, text and intent, or 3 and 4 with augmented and source, found %d", len(fields))})
+			continue
+		}
+		rec := record{Text: fields[0], Intent: fields[1]}
+		if len(fields) == 4 {
+			rec.Source = fields[3]
+		}
+		if len(fields) >= 3 && strings.TrimSpace(fields[2]) != "" {
+			if rec.Augmented, err = strconv.ParseBool(strings.TrimSpace(fields[2])); err != nil {
+				line, column := reader.FieldPos(2)
+				errs = append(errs, &ParseError{File: name, Line: line, Column: column, Msg: fmt.Sprintf("augmented must be true or false, found %q", fields[2])})
+				continue
+			}
+		}
+		example, perr := rec.example(name, line, column)
+		if perr != nil {
+			errs = append(errs, perr)
+			continue
+		}
+		dataset.Examples = append(dataset.Examples, example)
+	}
+	return readResult(dataset, errs)
+}
+
+//jsonlReader reads a {"text": ..., "intent": ...} object on every line
+type jsonlReader struct{}
+
+func (jsonlReader) Read(r io.Reader, name string) (*Dataset, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<30)
+	dataset := &Dataset{}
+	var errs ParseErrors
+	for number := 1; scanner.Scan(); number++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if number == 1 {
+			line = bytes.TrimPrefix(line, []byte("\uFEFF"))
+		}
+		if len(line) == 0 {
+			continue
+		}
+		var rec record
+		if err := json.Unmarshal(line, &rec); err != nil {
+			errs = append(errs, &ParseError{File: name, Line: number, Column: jsonColumn(err), Msg: err.Error()})
+			continue
+		}
+		example, perr := rec.example(name, number, 1)
+		if perr != nil {
+			errs = append(errs, perr)
+			continue
+		}
+		dataset.Examples = append(dataset.Examples, example)
+	}
+	if err := scanner.Err(); err != nil {
+		return dataset, err
+	}
+	return readResult(dataset, errs)
+}
+
+//This function gets the column of a json syntax error, or 1 when it has no position
+func jsonColumn(err error) int {
+	if serr, ok := err.(*json.SyntaxError); ok {
+		return int(serr.Offset)
+	}
+	return 1
+}
+
+//jsonReader reads an array of {"text": ..., "intent": ...} objects
+type jsonReader struct{}
+
+func (jsonReader) Read(r io.Reader, name string) (*Dataset, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	content = bytes.TrimPrefix(content, []byte("\uFEFF"))
+	//Get the line and column of an offset of the content, columns count characters from 1
+	position := func(offset int64) (int, int) {
+		before := content[:offset]
+		line := bytes.Count(before, []byte("\n")) + 1
+		return line, utf8.RuneCount(before[bytes.LastIndexByte(before, '\n')+1:]) + 1
+	}
+	dataset := &Dataset{}
+	var errs ParseErrors
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	if token, err := decoder.Token(); err != nil || token != json.Delim('[') {
+		line, column := position(decoder.InputOffset())
+		return dataset, ParseErrors{{File: name, Line: line, Column: column, Msg: "expected an array of {\"text\", \"intent\"} objects"}}
+	}
+	for decoder.More() {
+		//The offset is right after the previous value, skip the comma and spaces to get to this one
+		offset := decoder.InputOffset()
+		for offset < int64(len(content)) && strings.ContainsRune(", \t\r\n", rune(content[offset])) {
+			offset++
+		}
+		line, column := position(offset)
+		var rec record
+		if err := decoder.Decode(&rec); err != nil {
+			//A syntax error leaves the decoder lost, the rest of the file can't be read
+			if serr, ok := err.(*json.SyntaxError); ok {
+				if serr.Offset > 0 {
+					line, column = position(serr.Offset - 1)
+				}
+				errs = append(errs, &ParseError{File: name, Line: line, Column: column, Msg: err.Error()})
+				break
+			}
+			errs = append(errs, &ParseError{File: name, Line: line, Column: column, Msg: err.Error()})
+			continue
+		}
+		example, perr := rec.example(name, line, column)
+		if perr != nil {
+			errs = append(errs, perr)
+			continue
+		}
+		dataset.Examples = append(dataset.Examples, example)
+	}
+	return readResult(dataset, errs)
+}
+
+//yamlReader reads the examples and responses of every intent together:
+//
+//	intents:
+//	  greeting:
+//	    examples:
+//	      - hola
+//	      - buenos días
+//	    augmented:
+//	      - text: buenos dias
+//	        source: buenos días
+//	    responses:
+//	      - Hola! Bienvenido
+type yamlReader struct{}
+
+//yamlIntents is the layout of a yaml dataset
+type yamlIntents struct {
+	Intents map[string]struct {
+		Examples  []yaml.Node
+		Augmented []yaml.Node
+		Responses []string
+	}
+}
+
+func (yamlReader) Read(r io.Reader, name string) (*Dataset, error) {
+	var data yamlIntents
+	if err := yaml.NewDecoder(r).Decode(&data); err != nil && err != io.EOF {
+		return nil, ParseErrors{yamlError(name, err)}
+	}
+	dataset := &Dataset{Responses: make(map[string][]string)}
+	var errs ParseErrors
+	//Go through the intents in order, so the examples are always in the same order
+	var intents []string
+	for intent := range data.Intents {
+		intents = append(intents, intent)
+	}
+	sort.Strings(intents)
+	for _, intent := range intents {
+		entry := data.Intents[intent]
+		nodes := append(append([]yaml.Node(nil), entry.Examples...), entry.Augmented...)
+		for i, node := range nodes {
+			//The generated examples are apart, after the original ones, with the sentence they were made from
+			rec := record{Text: node.Value, Intent: intent, Augmented: i >= len(entry.Examples)}
+			var variant yamlVariant
+			switch {
+			case node.Kind == yaml.ScalarNode:
+			case rec.Augmented && node.Kind == yaml.MappingNode && node.Decode(&variant) == nil:
+				rec.Text, rec.Source = variant.Text, variant.Source
+			case rec.Augmented:
+				errs = append(errs, &ParseError{File: name, Line: node.Line, Column: node.Column, Msg: "a generated example must be a sentence, or its text and source"})
+				continue
+			default:
+				errs = append(errs, &ParseError{File: name, Line: node.Line, Column: node.Column, Msg: "an example must be a sentence"})
+				continue
+			}
+			example, perr := rec.example(name, node.Line, node.Column)
+			if perr != nil {
+				errs = append(errs, perr)
+				continue
+			}
+			dataset.Examples = append(dataset.Examples, example)
+		}
+		if len(entry.Responses) > 0 {
+			dataset.Responses[intent] = entry.Responses
+		}
+	}
+	return readResult(dataset, errs)
+}
+
+//This function gets the position of a yaml error, the decoder only gives its line inside the message
+func yamlError(name string, err error) *ParseError {
+	perr := &ParseError{File: name, Line: 1, Column: 1, Msg: strings.TrimPrefix(err.Error(), "yaml: ")}
+	var line int
+	if _, scan := fmt.Sscanf(perr.Msg, "line %d:", &line); scan == nil {
+		perr.Line, perr.Msg = line, strings.TrimSpace(perr.Msg[strings.Index(perr.Msg, ":")+1:])
+	}
+	return perr
+}
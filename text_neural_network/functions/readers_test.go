@@ -0,0 +1,137 @@
+package functions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReaders(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    []Example
+		//"line:column: message" of every error, the message may be only part of it
+		errs []string
+	}{
+		{"csv with header", "data.csv", "text,intent\nhola,greeting\n\"hola, amigo\",greeting\n",
+			[]Example{{Text: "hola", Category: "greeting", Line: 2}, {Text: "hola, amigo", Category: "greeting", Line: 3}}, nil},
+		{"csv with byte order mark", "data.csv", "\uFEFFText,Intent\r\nhola,greeting\r\n",
+			[]Example{{Text: "hola", Category: "greeting", Line: 2}}, nil},
+		{"csv without header", "data.CSV", "hola,greeting\n,noanswer\n",
+			[]Example{{Text: "hola", Category: "greeting", Line: 1}, {Text: "", Category: "noanswer", Line: 2}}, nil},
+		{"csv generated examples", "data.csv", "text,intent,augmented,source\nhola,greeting,,\nola,greeting,true,hola\nhi,greeting,TRUE\n",
+			[]Example{{Text: "hola", Category: "greeting", Line: 2}, {Text: "ola", Category: "greeting", Line: 3, Generated: true, Source: "hola"},
+				{Text: "hi", Category: "greeting", Line: 4, Generated: true}}, nil},
+		{"csv errors", "data.csv", "hola\nhola,\n,greeting\nhola,greeting,maybe\na,b,c,d,e\nadios,goodbye\n",
+			[]Example{{Text: "adios", Category: "goodbye", Line: 6}},
+			[]string{"1:1: expected 2 fields", "2:1: missing intent", "3:1: missing text", "4:15: augmented must be true or false", "5:1: expected 2 fields"}},
+		{"csv quotes", "data.csv", "hola,greeting\n\"hola,greeting\n",
+			[]Example{{Text: "hola", Category: "greeting", Line: 1}}, []string{"2:16: extraneous or missing \" in quoted-field"}},
+
+		{"jsonl", "data.jsonl", "{\"text\": \"hola\", \"intent\": \"greeting\"}\n\n{\"text\": \"adios\", \"intent\": \"goodbye\", \"augmented\": true, \"source\": \"adiós\"}\n",
+			[]Example{{Text: "hola", Category: "greeting", Line: 1}, {Text: "adios", Category: "goodbye", Line: 3, Generated: true, Source: "adiós"}}, nil},
+		{"jsonl errors", "data.jsonl", "{\"text\": \"hola\"}\n{\"text\": \"hola\",}\n{\"text\": 5, \"intent\": \"x\"}\n{\"text\": \"adios\", \"intent\": \"goodbye\"}\n",
+			[]Example{{Text: "adios", Category: "goodbye", Line: 4}},
+			[]string{"1:1: missing intent", "2:17: invalid character '}'", "3:1: cannot unmarshal number"}},
+
+		{"json", "data.json", "[\n  {\"text\": \"hola\", \"intent\": \"greeting\"},\n  {\"text\": \"adios\", \"intent\": \"goodbye\"}\n]\n",
+			[]Example{{Text: "hola", Category: "greeting", Line: 2}, {Text: "adios", Category: "goodbye", Line: 3}}, nil},
+		{"json not an array", "data.json", "{\"text\": \"hola\"}", nil, []string{"1:2: expected an array"}},
+		{"json errors", "data.json", "[{\"text\": \"hola\", \"intent\": \"\"},\n {\"text\": 5, \"intent\": \"x\"},\n {\"text\": \"adiós\", \"intent\": \"goodbye\"},\n {\"text\" \"x\"}]",
+			[]Example{{Text: "adiós", Category: "goodbye", Line: 3}},
+			[]string{"1:2: missing intent", "2:2: cannot unmarshal number", "4:10: invalid character '\"'"}},
+
+		{"yaml", "data.yaml", "intents:\n  greeting:\n    examples:\n      - hola\n      - buenos días\n    augmented:\n      - ola\n      - text: buenos dias\n        source: buenos días\n    responses:\n      - Hola!\n  food:\n    examples:\n      - pizza\n",
+			[]Example{{Text: "pizza", Category: "food", Line: 14}, {Text: "hola", Category: "greeting", Line: 4}, {Text: "buenos días", Category: "greeting", Line: 5},
+				{Text: "ola", Category: "greeting", Line: 7, Generated: true}, {Text: "buenos dias", Category: "greeting", Line: 8, Generated: true, Source: "buenos días"}}, nil},
+		{"yaml errors", "data.yml", "intents:\n  greeting:\n    examples:\n      - text: hola\n      - \"\"\n    augmented:\n      - [ola]\n",
+			nil, []string{"4:9: an example must be a sentence", "5:9: missing text", "7:9: a generated example must be a sentence"}},
+		{"yaml syntax error", "data.yaml", "intents:\n  greeting:\n    examples: [hola\n", nil, []string{"2:1: did not find expected ',' or ']'"}},
+	}
+	for _, test := range tests {
+		reader, err := ReaderFor(test.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dataset, err := reader.Read(strings.NewReader(test.content), test.file)
+		var examples []Example
+		if dataset != nil {
+			examples = dataset.Examples
+		}
+		if !reflect.DeepEqual(examples, test.want) {
+			t.Errorf("%s: examples %+v, want %+v", test.name, examples, test.want)
+		}
+		var errs ParseErrors
+		if len(test.errs) == 0 {
+			if err != nil {
+				t.Errorf("%s: error = %v", test.name, err)
+			}
+			continue
+		}
+		if !errors.As(err, &errs) || len(errs) != len(test.errs) {
+			t.Errorf("%s: error = %v, want %d errors", test.name, err, len(test.errs))
+			continue
+		}
+		for i, perr := range errs {
+			position := fmt.Sprintf("%d:%d: ", perr.Line, perr.Column)
+			want := strings.SplitN(test.errs[i], " ", 2)
+			if perr.File != test.file || position != want[0]+" " || !strings.Contains(perr.Msg, want[1]) {
+				t.Errorf("%s: error %v, want %s:%s", test.name, perr, test.file, test.errs[i])
+			}
+		}
+	}
+}
+
+func TestYamlResponses(t *testing.T) {
+	content := "intents:\n  greeting:\n    examples: [hola]\n    responses: [Hola!, Buenas]\n  food:\n    examples: [pizza]\n"
+	dataset, err := yamlReader{}.Read(strings.NewReader(content), "data.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"greeting": {"Hola!", "Buenas"}}
+	if !reflect.DeepEqual(dataset.Responses, want) {
+		t.Errorf("responses %v, want %v", dataset.Responses, want)
+	}
+}
+
+//tsvReader reads a text and an intent separated by a tab on every line
+type tsvReader struct{}
+
+func (tsvReader) Read(r io.Reader, name string) (*Dataset, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return csvReader{}.Read(strings.NewReader(strings.Replace(string(content), "\t", ",", -1)), name)
+}
+
+func TestReaderFor(t *testing.T) {
+	RegisterReader(".TSV", tsvReader{})
+	defer delete(readers, ".tsv")
+	tests := []struct {
+		file string
+		want DatasetReader
+	}{
+		{"chatss.txt", phrasesReader{}},
+		{"data.Json", jsonReader{}},
+		{"data.yml", yamlReader{}},
+		{"data.tsv", tsvReader{}},
+		{"data.xml", nil},
+		{"data", nil},
+	}
+	for _, test := range tests {
+		got, err := ReaderFor(test.file)
+		if (err != nil) != (test.want == nil) || got != test.want {
+			t.Errorf("ReaderFor(%q) = %T, %v, want %T", test.file, got, err, test.want)
+		}
+	}
+	if _, err := LoadDataset("missing.csv"); err == nil {
+		t.Error("LoadDataset of a missing file didn't fail")
+	}
+}
@@ -0,0 +1,222 @@
+package functions
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+//This file is the Snowball stemmer for spanish, https://snowballstem.org/algorithms/spanish/stemmer.html.
+//It cuts the endings of a word so "pizza" and "pizzas", or "ordenar" and "ordenamos", are the same word
+
+//Pronouns that can be attached to the end of a verb, like "dámelo" or "comiéndola"
+var spanish_pronouns = []string{"me", "se", "sela", "selo", "selas", "selos", "la", "le", "lo", "las", "les", "los", "nos"}
+
+//Endings of the verbs that can have a pronoun attached
+var spanish_pronoun_verbs = []string{"iéndo", "ándo", "ár", "ér", "ír", "ando", "iendo", "ar", "er", "ir", "yendo"}
+
+//Endings of nouns, adjectives and adverbs, grouped by what is done with them
+var spanish_standard = map[string]int{
+	"anza": 1, "anzas": 1, "ico": 1, "ica": 1, "icos": 1, "icas": 1, "ismo": 1, "ismos": 1, "able": 1, "ables": 1,
+	"ible": 1, "ibles": 1, "ista": 1, "istas": 1, "oso": 1, "osa": 1, "osos": 1, "osas": 1, "amiento": 1, "amientos": 1,
+	"imiento": 1, "imientos": 1,
+	"adora": 2, "ador": 2, "ación": 2, "adoras": 2, "adores": 2, "aciones": 2, "ante": 2, "antes": 2, "ancia": 2, "ancias": 2,
+	"logía": 3, "logías": 3, "ución": 4, "uciones": 4, "encia": 5, "encias": 5,
+	"amente": 6, "mente": 7, "idad": 8, "idades": 8,
+	"iva": 9, "ivo": 9, "ivas": 9, "ivos": 9,
+}
+
+//The endings of nouns, adjectives and adverbs to look for
+var spanish_standard_suffixes = suffixKeys(spanish_standard)
+
+//Endings of the verbs that start with y, only removed after an u, like "construyo"
+var spanish_y_verbs = []string{"ya", "ye", "yan", "yen", "yeron", "yendo", "yo", "yó", "yas", "yes", "yais", "yamos"}
+
+//Endings of the other verbs
+var spanish_verbs = []string{"en", "es", "éis", "emos",
+	"arían", "arías", "arán", "arás", "aríais", "aría", "aréis", "aríamos", "aremos", "ará", "aré",
+	"erían", "erías", "erán", "erás", "eríais", "ería", "eréis", "eríamos", "eremos", "erá", "eré",
+	"irían", "irías", "irán", "irás", "iríais", "iría", "iréis", "iríamos", "iremos", "irá", "iré",
+	"aba", "ada", "ida", "ía", "ara", "iera", "ad", "ed", "id", "ase", "iese", "aste", "iste", "an", "aban", "ían",
+	"aran", "ieran", "asen", "iesen", "aron", "ieron", "ado", "ido", "ando", "iendo", "ió", "ar", "er", "ir", "as",
+	"abas", "adas", "idas", "ías", "aras", "ieras", "ases", "ieses", "ís", "áis", "abais", "íais", "arais", "ierais",
+	"aseis", "ieseis", "asteis", "isteis", "ados", "idos", "amos", "ábamos", "íamos", "imos", "áramos", "iéramos",
+	"iésemos", "ásemos"}
+
+//Endings left after the others were removed
+var spanish_residual = []string{"os", "a", "o", "á", "í", "ó", "e", "é"}
+
+//This function tells if a letter is a spanish vowel
+func isSpanishVowel(r rune) bool {
+	return strings.ContainsRune("aeiouáéíóúü", r)
+}
+
+//This function gets the stem of a lowercase spanish word
+func StemSpanish(word string) string {
+	w := []rune(word)
+	rv, r1, r2 := spanishRegions(w)
+
+	//Step 0: remove the pronoun attached to a verb, and the accent it made the verb have
+	if pronoun := longestSuffix(w, 0, spanish_pronouns); pronoun != "" {
+		end := len(w) - utf8.RuneCountInString(pronoun)
+		verb := longestSuffix(w[:end], 0, spanish_pronoun_verbs)
+		start := end - utf8.RuneCountInString(verb)
+		if verb != "" && start >= rv {
+			switch verb {
+			case "iéndo", "ándo", "ár", "ér", "ír":
+				w = append(w[:start], []rune(removeAccents(verb))...)
+			case "yendo":
+				if start > 0 && w[start-1] == 'u' {
+					w = w[:end]
+				}
+			default:
+				w = w[:end]
+			}
+		}
+	}
+
+	//Step 1: remove the endings of nouns, adjectives and adverbs,
+	//or step 2 the endings of verbs when there was none of those
+	var removed bool
+	if w, removed = spanishStandard(w, r1, r2); !removed {
+		if suffix := longestSuffix(w, rv, spanish_y_verbs); suffix != "" {
+			start := len(w) - utf8.RuneCountInString(suffix)
+			if start > 0 && w[start-1] == 'u' {
+				w, removed = w[:start], true
+			}
+		}
+		if suffix := longestSuffix(w, rv, spanish_verbs); !removed && suffix != "" {
+			start := len(w) - utf8.RuneCountInString(suffix)
+			switch suffix {
+			case "en", "es", "éis", "emos":
+				//The u of "gu" only makes the g sound hard before the e
+				if start >= 2 && w[start-1] == 'u' && w[start-2] == 'g' {
+					start--
+				}
+			}
+			w = w[:start]
+		}
+	}
+
+	//Step 3: remove the last vowel
+	if suffix := longestSuffix(w, 0, spanish_residual); suffix != "" {
+		start := len(w) - utf8.RuneCountInString(suffix)
+		if start >= rv {
+			w = w[:start]
+			if (suffix == "e" || suffix == "é") && start-1 >= rv && w[start-1] == 'u' && start >= 2 && w[start-2] == 'g' {
+				w = w[:start-1]
+			}
+		}
+	}
+	return removeAccents(string(w))
+}
+
+//This function removes the endings of nouns, adjectives and adverbs, it gets false when there is none to remove
+func spanishStandard(w []rune, r1 int, r2 int) ([]rune, bool) {
+	suffix := longestSuffix(w, 0, spanish_standard_suffixes)
+	if suffix == "" {
+		return w, false
+	}
+	start := len(w) - utf8.RuneCountInString(suffix)
+	//Remove an ending before the end of the word, if it is on the region
+	remove := func(w []rune, endings []string, region int) ([]rune, string) {
+		if ending := longestSuffix(w, 0, endings); ending != "" {
+			if start := len(w) - utf8.RuneCountInString(ending); start >= region {
+				return w[:start], ending
+			}
+		}
+		return w, ""
+	}
+	group := spanish_standard[suffix]
+	region := r2
+	if group == 6 {
+		region = r1
+	}
+	if start < region {
+		return w, false
+	}
+	w = w[:start]
+	switch group {
+	case 2:
+		w, _ = remove(w, []string{"ic"}, r2)
+	case 3:
+		w = append(w, []rune("log")...)
+	case 4:
+		w = append(w, 'u')
+	case 5:
+		w = append(w, []rune("ente")...)
+	case 6:
+		var ending string
+		if w, ending = remove(w, []string{"iv", "os", "ic", "ad"}, r2); ending == "iv" {
+			w, _ = remove(w, []string{"at"}, r2)
+		}
+	case 7:
+		w, _ = remove(w, []string{"ante", "able", "ible"}, r2)
+	case 8:
+		w, _ = remove(w, []string{"abil", "ic", "iv"}, r2)
+	case 9:
+		w, _ = remove(w, []string{"at"}, r2)
+	}
+	return w, true
+}
+
+//This function gets where the regions of a word start, the parts of it endings are removed from.
+//RV is after the next vowel when the second letter is a consonant, after the next consonant
+//when the first two letters are vowels, and after the third letter otherwise. R1 is after the
+//first consonant that follows a vowel, and R2 is the R1 of R1. A region not found is the end of the word
+func spanishRegions(w []rune) (int, int, int) {
+	n := len(w)
+	rv, r1, r2 := n, n, n
+	//Position after the first letter from i that is, or isn't, a vowel
+	past := func(i int, vowel bool) int {
+		for ; i < n; i++ {
+			if isSpanishVowel(w[i]) == vowel {
+				return i + 1
+			}
+		}
+		return n
+	}
+	if n >= 2 {
+		switch {
+		case !isSpanishVowel(w[1]):
+			rv = past(2, true)
+		case isSpanishVowel(w[0]):
+			rv = past(2, false)
+		case n >= 3:
+			rv = 3
+		}
+	}
+	r1 = past(past(0, true), false)
+	if r1 < n {
+		r2 = past(past(r1, true), false)
+	}
+	return rv, r1, r2
+}
+
+//This function gets the longest of the suffixes a word ends with that starts at limit or after, or ""
+func longestSuffix(w []rune, limit int, suffixes []string) string {
+	if limit > len(w) {
+		return ""
+	}
+	word := string(w[limit:])
+	longest := ""
+	for _, suffix := range suffixes {
+		if len(suffix) > len(longest) && strings.HasSuffix(word, suffix) {
+			longest = suffix
+		}
+	}
+	return longest
+}
+
+//This function removes the acute accents of the vowels, the ü is kept
+func removeAccents(word string) string {
+	return strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u").Replace(word)
+}
+
+//This function gets the keys of a map of suffixes
+func suffixKeys(m map[string]int) []string {
+	list := make([]string, 0, len(m))
+	for k := range m {
+		list = append(list, k)
+	}
+	return list
+}
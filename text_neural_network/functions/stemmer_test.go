@@ -0,0 +1,159 @@
+package functions
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStemSpanish(t *testing.T) {
+	//The stems of the Snowball reference stemmer
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"quiero", "quier"},
+		{"queremos", "quer"},
+		{"quisiera", "quis"},
+		{"gustaría", "gust"},
+		{"encantó", "encant"},
+		{"comiendo", "com"},
+		{"corriendo", "corr"},
+		{"canciones", "cancion"},
+		{"cantando", "cant"},
+		{"cantaba", "cant"},
+		{"hablaremos", "habl"},
+		{"habló", "habl"},
+		{"rápidamente", "rapid"},
+		{"felicidad", "felic"},
+		{"nacionalidad", "nacional"},
+		{"organización", "organiz"},
+		{"organizaciones", "organiz"},
+		{"lógica", "logic"},
+		{"lógicos", "logic"},
+		{"chicas", "chic"},
+		{"niños", "niñ"},
+		{"niño", "niñ"},
+		{"ñandú", "ñandu"},
+		{"pizzas", "pizz"},
+		{"pizza", "pizz"},
+		{"adiós", "adios"},
+		{"hola", "hol"},
+		{"buenos", "buen"},
+		{"días", "dias"},
+		{"noches", "noch"},
+		{"comer", "com"},
+		{"bebidas", "beb"},
+		{"agua", "agu"},
+		{"preguntándole", "pregunt"},
+		{"ayúdame", "ayudam"},
+		{"gracias", "graci"},
+		{"muchísimas", "muchisim"},
+		{"tranquilamente", "tranquil"},
+		{"inteligentemente", "inteligent"},
+		{"abundancia", "abund"},
+		{"abundante", "abund"},
+		//Short words have no regions to cut
+		{"a", "a"},
+		{"de", "de"},
+		{"yo", "yo"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := StemSpanish(test.word); got != test.want {
+			t.Errorf("StemSpanish(%q) = %q, want %q", test.word, got, test.want)
+		}
+	}
+}
+
+func TestLoadLemmas(t *testing.T) {
+	dir := t.TempDir()
+	file := writeTestFile(t, dir, "lemmas.txt", "//Comments are ignored\n\nQuerer quiero quisiera\ngustar gustaría\n")
+	lemmas, err := LoadLemmas(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//The words are also found without their accents
+	want := map[string]string{"querer": "querer", "quiero": "querer", "quisiera": "querer", "gustar": "gustar", "gustaría": "gustar", "gustaria": "gustar"}
+	if !reflect.DeepEqual(lemmas, want) {
+		t.Errorf("lemmas %v, want %v", lemmas, want)
+	}
+	_, err = LoadLemmas(writeTestFile(t, dir, "bad.txt", "querer quiero\n\nquerer\n"))
+	var perr *ParseError
+	if !errors.As(err, &perr) || perr.Line != 3 || perr.Column != 1 {
+		t.Errorf("error = %v, want one on line 3", err)
+	}
+	if _, err := LoadLemmas(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Error("LoadLemmas of a missing file didn't fail")
+	}
+}
+
+func TestLemmasFile(t *testing.T) {
+	lemmas, err := LoadLemmas(filepath.Join("..", "lemmas.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	//Every word is read as a verb that means the same, not a close one
+	tests := map[string]string{
+		"quisiera":  "querer",
+		"gustaría":  "gustar",
+		"encanta":   "encantar",
+		"encantó":   "encantar",
+		"encanto":   "encantar",
+		"ordenamos": "ordenar",
+		"pido":      "pedir",
+		"pedir":     "pedir",
+		"pedimos":   "pedir",
+	}
+	for word, want := range tests {
+		if got := lemmas[word]; got != want {
+			t.Errorf("%q is read as %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestStemAndLemmas(t *testing.T) {
+	lemmas := map[string]string{"quiero": "querer", "gustaría": "gustar", "gustaria": "gustar"}
+	sentence := "Quiero dos pizzas, ¿rápidamente? Me gustaría"
+	tests := []struct {
+		name   string
+		stem   bool
+		lemmas map[string]string
+		want   []string
+	}{
+		{"default", false, nil, []string{"quiero", "dos", "pizzas,", "¿rapidamente?", "gustaria"}},
+		{"lemmas", false, lemmas, []string{"querer", "dos", "pizzas,", "¿rapidamente?", "gustar"}},
+		//The punctuation around the words is kept
+		{"stem", true, nil, []string{"quier", "dos", "pizz,", "¿rapid?", "gust"}},
+		//The lemmas go before the stemmer
+		{"lemmas and stem", true, lemmas, []string{"quer", "dos", "pizz,", "¿rapid?", "gust"}},
+	}
+	for _, test := range tests {
+		p := NewPreprocessing(DefaultPreprocessing().Steps, test.stem, test.lemmas, nil)
+		if got := scanWords(sentence, p.build()); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: words %q, want %q", test.name, got, test.want)
+		}
+	}
+}
+
+func TestMapLetters(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"hola", "HOLA"},
+		{"¿hola?", "¿HOLA?"},
+		{"coca-cola!", "COCA-COLA!"},
+		{"niño.", "NIÑO."},
+		{"123", "123"},
+		{"", ""},
+	}
+	for _, test := range tests {
+		got := mapLetters(test.text, strings.ToUpper)
+		if got != test.want {
+			t.Errorf("mapLetters(%q) = %q, want %q", test.text, got, test.want)
+		}
+	}
+}
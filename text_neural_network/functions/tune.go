@@ -0,0 +1,242 @@
+package functions
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//SearchSpace are the values to try for every hyperparameter, an empty list keeps the value of the base configuration
+type SearchSpace struct {
+	//Hidden layers, written like ParseLayers reads them
+	Layers []string
+	Alpha  []float64
+	Epochs []int
+	//A dropout percent of 0 trains without dropout
+	DropoutPercent []float64
+	//Features of the words, binary, tf or tfidf
+	Features []string
+}
+
+//Trial is a candidate configuration of the search and its score on the held out sentences
+type Trial struct {
+	Layers         string
+	Alpha          float64
+	Epochs         int
+	DropoutPercent float64
+	Features       string
+	Accuracy       float64
+	MacroF1        float64
+	//Epochs the candidate trained before stopping
+	Trained  int
+	Duration time.Duration
+	model    *Model
+}
+
+//This function loads a search space from a json file
+func LoadSearchSpace(file string) (SearchSpace, error) {
+	var space SearchSpace
+	content, err := ioutil.ReadFile(file)
+	if err != nil {
+		return space, err
+	}
+	err = json.Unmarshal(content, &space)
+	return space, err
+}
+
+//This function searches the hyperparameters of the network. It holds out a fraction of the sentences
+//of every category, trains every candidate with the rest on parallel goroutines, and gets a
+//leaderboard sorted from the best to the worst accuracy on the held out sentences, with the best model.
+//The search is either "grid", every combination of the space, or "random", that many random combinations.
+//The generated sentences are only trained with, never held out, and only when the sentence they were made from is
+func Tune(db map[string][]string, generated []Example, base TrainConfig, space SearchSpace, search string, trials int, workers int, holdout float64) ([]*Trial, *Model, error) {
+	//The random candidates and the held out sentences come from the seed of the base configuration
+	rng := rand.New(rand.NewSource(base.Seed))
+	candidates, err := candidates(base, space, search, trials, rng)
+	if err != nil {
+		return nil, nil, err
+	}
+	if holdout <= 0 || holdout >= 1 {
+		return nil, nil, fmt.Errorf("the held out fraction must be between 0 and 1, got %v", holdout)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+	//Every candidate is scored on the same held out sentences
+	train_db, held_db := SplitDb(db, holdout, rng)
+	train_db, base.Sources = JoinGenerated(train_db, generated)
+	words, _ := Vocabulary(train_db, base.preprocessing())
+	_, categories := Vocabulary(db, base.preprocessing())
+	x, y := CountWords(train_db, words, categories, base.preprocessing())
+	fmt.Printf("Trying %d candidates with %d workers\n", len(candidates), workers)
+
+	err = runTrials(candidates, workers, func(t *Trial) error {
+		if err := t.run(x, y, base, words, categories, held_db); err != nil {
+			return err
+		}
+		fmt.Printf("layers=%s alpha=%v epochs=%v dropout=%v features=%s: accuracy %.4f, f1 %.4f\n", t.Layers, t.Alpha, t.Epochs, t.DropoutPercent, t.Features, t.Accuracy, t.MacroF1)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	//Best accuracy first, the F1 breaks the ties
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Accuracy != candidates[j].Accuracy {
+			return candidates[i].Accuracy > candidates[j].Accuracy
+		}
+		return candidates[i].MacroF1 > candidates[j].MacroF1
+	})
+	return candidates, candidates[0].model, nil
+}
+
+//This function runs every trial on that many goroutines. After the first error no more trials
+//are started, the ones already running finish, and that error is returned
+func runTrials(trials []*Trial, workers int, run func(t *Trial) error) error {
+	jobs := make(chan *Trial)
+	//Closed on the first error, to stop handing out trials
+	failed := make(chan struct{})
+	var mu sync.Mutex
+	var first_err error
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range jobs {
+				err := run(t)
+				mu.Lock()
+				if err != nil && first_err == nil {
+					first_err = err
+					close(failed)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+dispatch:
+	for _, t := range trials {
+		select {
+		case jobs <- t:
+		case <-failed:
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return first_err
+}
+
+//This function trains a candidate and scores it on the held out sentences
+func (t *Trial) run(x *mat.Dense, y *mat.Dense, base TrainConfig, words []string, categories []string, held map[string][]string) error {
+	config := base
+	layers, err := ParseLayers(t.Layers)
+	if err != nil {
+		return err
+	}
+	config.Layers, config.Alpha, config.Epochs = layers, t.Alpha, t.Epochs
+	config.Dropout, config.DropoutPercent = t.DropoutPercent > 0, t.DropoutPercent
+	config.Features = t.Features
+	config.Quiet = true
+	start := time.Now()
+	model, err := Train(x, y, config, words, categories)
+	if err != nil {
+		return err
+	}
+	t.Duration = time.Since(start)
+	report := Evaluate(model, held)
+	t.Accuracy, t.MacroF1, t.Trained, t.model = report.Accuracy, report.MacroF1, model.Metrics.Epochs, model
+	return nil
+}
+
+//This function gets the configurations to try, every combination of the space or random ones
+func candidates(base TrainConfig, space SearchSpace, search string, trials int, rng *rand.Rand) ([]*Trial, error) {
+	//Empty dimensions keep the base value
+	if len(space.Layers) == 0 {
+		var layers []string
+		for _, l := range base.Layers {
+			layers = append(layers, l.String())
+		}
+		space.Layers = []string{strings.Join(layers, ",")}
+	}
+	if len(space.Alpha) == 0 {
+		space.Alpha = []float64{base.Alpha}
+	}
+	if len(space.Epochs) == 0 {
+		space.Epochs = []int{base.Epochs}
+	}
+	if len(space.DropoutPercent) == 0 {
+		dropout := 0.0
+		if base.Dropout {
+			dropout = base.DropoutPercent
+		}
+		space.DropoutPercent = []float64{dropout}
+	}
+	if len(space.Features) == 0 {
+		space.Features = []string{base.Features}
+	}
+	//Check every layers description and features before starting to train
+	for _, layers := range space.Layers {
+		if _, err := ParseLayers(layers); err != nil {
+			return nil, err
+		}
+	}
+	for i, features := range space.Features {
+		extractor, err := NewFeatureExtractor(features)
+		if err != nil {
+			return nil, err
+		}
+		space.Features[i] = extractor.State().Name
+	}
+
+	var candidates []*Trial
+	switch search {
+	case "grid":
+		for _, layers := range space.Layers {
+			for _, alpha := range space.Alpha {
+				for _, epochs := range space.Epochs {
+					for _, dropout := range space.DropoutPercent {
+						for _, features := range space.Features {
+							candidates = append(candidates, &Trial{Layers: layers, Alpha: alpha, Epochs: epochs, DropoutPercent: dropout, Features: features})
+						}
+					}
+				}
+			}
+		}
+	case "random":
+		if trials <= 0 {
+			return nil, fmt.Errorf("random search needs a positive number of trials, got %d", trials)
+		}
+		for i := 0; i < trials; i++ {
+			candidates = append(candidates, &Trial{
+				Layers:         space.Layers[rng.Intn(len(space.Layers))],
+				Alpha:          space.Alpha[rng.Intn(len(space.Alpha))],
+				Epochs:         space.Epochs[rng.Intn(len(space.Epochs))],
+				DropoutPercent: space.DropoutPercent[rng.Intn(len(space.DropoutPercent))],
+				Features:       space.Features[rng.Intn(len(space.Features))],
+			})
+		}
+	default:
+		return nil, fmt.Errorf("unknown search %q, it must be grid or random", search)
+	}
+	return candidates, nil
+}
+
+//This function writes the leaderboard as a table, the best candidate first
+func PrintLeaderboard(out io.Writer, leaderboard []*Trial) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "rank\tlayers\talpha\tepochs\tdropout\tfeatures\taccuracy\tf1\ttrained\ttime")
+	for i, t := range leaderboard {
+		fmt.Fprintf(w, "%d\t%s\t%v\t%d\t%v\t%s\t%.4f\t%.4f\t%d\t%s\n", i+1, t.Layers, t.Alpha, t.Epochs, t.DropoutPercent, t.Features, t.Accuracy, t.MacroF1, t.Trained, t.Duration.Round(time.Millisecond))
+	}
+	w.Flush()
+}
@@ -0,0 +1,151 @@
+package functions
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestCandidates(t *testing.T) {
+	base := TrainConfig{Layers: []Layer{{20, SIGMOID}}, Alpha: 0.1, Epochs: 1000, Dropout: true, DropoutPercent: 0.2}
+	tests := []struct {
+		name   string
+		space  SearchSpace
+		search string
+		trials int
+		//Candidates wanted, and the one expected first
+		count int
+		first Trial
+		err   bool
+	}{
+		{"empty space keeps the base", SearchSpace{}, "grid", 0, 1,
+			Trial{Layers: "20:sigmoid", Alpha: 0.1, Epochs: 1000, DropoutPercent: 0.2, Features: BINARY}, false},
+		{"every combination", SearchSpace{Layers: []string{"10", "20:relu"}, Alpha: []float64{0.1, 0.01, 0.001}, Features: []string{TF, TFIDF}}, "grid", 0, 12,
+			Trial{Layers: "10", Alpha: 0.1, Epochs: 1000, DropoutPercent: 0.2, Features: TF}, false},
+		{"random trials", SearchSpace{Alpha: []float64{0.1, 0.01}, Epochs: []int{10, 20}}, "random", 5, 5, Trial{}, false},
+		{"random without trials", SearchSpace{}, "random", 0, 0, Trial{}, true},
+		{"invalid layers", SearchSpace{Layers: []string{"10:softmax"}}, "grid", 0, 0, Trial{}, true},
+		{"invalid features", SearchSpace{Features: []string{"word2vec"}}, "grid", 0, 0, Trial{}, true},
+		{"unknown search", SearchSpace{}, "bayesian", 0, 0, Trial{}, true},
+	}
+	for _, test := range tests {
+		got, err := candidates(base, test.space, test.search, test.trials, rand.New(rand.NewSource(1)))
+		if (err != nil) != test.err {
+			t.Errorf("%s: error = %v, want error %t", test.name, err, test.err)
+			continue
+		}
+		if len(got) != test.count {
+			t.Errorf("%s: %d candidates, want %d", test.name, len(got), test.count)
+			continue
+		}
+		if test.search == "grid" && test.count > 0 && !reflect.DeepEqual(*got[0], test.first) {
+			t.Errorf("%s: first candidate %+v, want %+v", test.name, *got[0], test.first)
+		}
+		//Random candidates only take values of the space
+		for _, trial := range got {
+			if test.search == "random" && (trial.Alpha != 0.1 && trial.Alpha != 0.01 || trial.Epochs != 10 && trial.Epochs != 20) {
+				t.Errorf("%s: candidate %+v is not on the space", test.name, *trial)
+			}
+		}
+	}
+}
+
+func TestLoadSearchSpace(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "space.json")
+	content := `{"Layers": ["10", "20:relu"], "Alpha": [0.1], "DropoutPercent": [0, 0.5], "Features": ["tfidf"]}`
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	space, err := LoadSearchSpace(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := SearchSpace{Layers: []string{"10", "20:relu"}, Alpha: []float64{0.1}, DropoutPercent: []float64{0, 0.5}, Features: []string{TFIDF}}
+	if !reflect.DeepEqual(space, want) {
+		t.Errorf("LoadSearchSpace = %+v, want %+v", space, want)
+	}
+	if _, err := LoadSearchSpace(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("LoadSearchSpace of a missing file didn't fail")
+	}
+}
+
+func TestTune(t *testing.T) {
+	db, _, _, _, _ := toyData()
+	space := SearchSpace{Layers: []string{"8", "4:tanh"}, Alpha: []float64{0.5, 0.05}}
+	tests := []struct {
+		holdout float64
+		workers int
+		err     bool
+	}{
+		{0.25, 1, false},
+		{0.25, 3, false},
+		{0, 2, true},
+		{1, 2, true},
+	}
+	for _, test := range tests {
+		leaderboard, best, err := Tune(db, nil, toyConfig(), space, "grid", 0, test.workers, test.holdout)
+		if (err != nil) != test.err {
+			t.Errorf("holdout %v: error = %v, want error %t", test.holdout, err, test.err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		if len(leaderboard) != 4 || best == nil || best != leaderboard[0].model {
+			t.Fatalf("workers %d: %d candidates and best model %p", test.workers, len(leaderboard), best)
+		}
+		//Best accuracy first, and the F1 breaks the ties
+		for i := 1; i < len(leaderboard); i++ {
+			a, b := leaderboard[i-1], leaderboard[i]
+			if a.Accuracy < b.Accuracy || a.Accuracy == b.Accuracy && a.MacroF1 < b.MacroF1 {
+				t.Errorf("workers %d: %+v is before %+v", test.workers, *a, *b)
+			}
+		}
+	}
+}
+
+func TestRunTrials(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name    string
+		workers int
+		//Trial that fails, -1 for none
+		fails int
+	}{
+		{"all of them", 3, -1},
+		{"one worker", 1, 2},
+		{"many workers", 4, 5},
+		{"the first one", 2, 0},
+	}
+	for _, test := range tests {
+		trials := make([]*Trial, 50)
+		for i := range trials {
+			trials[i] = &Trial{Epochs: i}
+		}
+		var mu sync.Mutex
+		ran := 0
+		err := runTrials(trials, test.workers, func(trial *Trial) error {
+			mu.Lock()
+			ran++
+			mu.Unlock()
+			if trial.Epochs == test.fails {
+				return errFailed
+			}
+			return nil
+		})
+		if test.fails < 0 {
+			if err != nil || ran != len(trials) {
+				t.Errorf("%s: ran %d trials with error %v, want %d without error", test.name, ran, err, len(trials))
+			}
+			continue
+		}
+		//The trials already handed out finish, and at most one more per worker starts while the error is saved
+		if !errors.Is(err, errFailed) || ran > test.fails+1+2*test.workers {
+			t.Errorf("%s: ran %d trials with error %v, want at most %d with %v", test.name, ran, err, test.fails+1+2*test.workers, errFailed)
+		}
+	}
+}
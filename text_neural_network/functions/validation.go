@@ -0,0 +1,136 @@
+package functions
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//TrainMetrics are the results of a training run on its validation examples
+type TrainMetrics struct {
+	//Epochs trained, and the epoch whose weights were kept
+	Epochs             int
+	BestEpoch          int     `json:",omitempty"`
+	ValidationLoss     float64 `json:",omitempty"`
+	ValidationAccuracy float64 `json:",omitempty"`
+}
+
+//This function splits the rows of y in training and validation rows, holding out
+//a fraction of the rows of every category. Every category keeps at least one row for training.
+//The generated rows, the ones with the row they were made from on sources, are never held out,
+//and they are left out of the training too when the row they were made from is held out
+func stratifiedSplit(y *mat.Dense, fraction float64, sources []int, rng *rand.Rand) ([]int, []int) {
+	r, _ := y.Dims()
+	//Group the rows by their category
+	rows := make(map[int][]int)
+	var order []int
+	var train, validation, generated []int
+	for i := 0; i < r; i++ {
+		if i < len(sources) && sources[i] >= 0 {
+			generated = append(generated, i)
+			continue
+		}
+		c := argmax(y.RawRowView(i))
+		if _, ok := rows[c]; !ok {
+			order = append(order, c)
+		}
+		rows[c] = append(rows[c], i)
+	}
+	for _, c := range order {
+		group := rows[c]
+		rng.Shuffle(len(group), func(i, j int) { group[i], group[j] = group[j], group[i] })
+		n := int(math.Round(float64(len(group)) * fraction))
+		if n >= len(group) {
+			n = len(group) - 1
+		}
+		validation = append(validation, group[:n]...)
+		train = append(train, group[n:]...)
+	}
+	held := make(map[int]bool)
+	for _, i := range validation {
+		held[i] = true
+	}
+	for _, i := range generated {
+		if !held[sources[i]] {
+			train = append(train, i)
+		}
+	}
+	return train, validation
+}
+
+//This function splits a database map in two, holding out a fraction of the sentences of every category.
+//Every category keeps at least one sentence on the first database
+func SplitDb(db map[string][]string, fraction float64, rng *rand.Rand) (map[string][]string, map[string][]string) {
+	train := make(map[string][]string)
+	held := make(map[string][]string)
+	var keys []string
+	for category := range db {
+		keys = append(keys, category)
+	}
+	sort.Strings(keys)
+	for _, category := range keys {
+		sentences := append([]string(nil), db[category]...)
+		rng.Shuffle(len(sentences), func(i, j int) { sentences[i], sentences[j] = sentences[j], sentences[i] })
+		n := int(math.Round(float64(len(sentences)) * fraction))
+		if n >= len(sentences) {
+			n = len(sentences) - 1
+		}
+		if n > 0 {
+			held[category] = sentences[:n]
+		}
+		train[category] = sentences[n:]
+	}
+	return train, held
+}
+
+//This function gets the mean loss of the network output against the expected output,
+//cross-entropy for softmax outputs and squared error for the rest
+func loss(output *mat.Dense, y *mat.Dense, activation string) float64 {
+	r, c := output.Dims()
+	var total float64
+	for i := 0; i < r; i++ {
+		for j := 0; j < c; j++ {
+			p, t := output.At(i, j), y.At(i, j)
+			if activation == SOFTMAX {
+				total -= t * math.Log(math.Max(p, 1e-15))
+			} else {
+				total += (t - p) * (t - p)
+			}
+		}
+	}
+	return total / float64(r)
+}
+
+//This function gets the fraction of rows where the highest output is the expected category
+func accuracy(output *mat.Dense, y *mat.Dense) float64 {
+	r, _ := output.Dims()
+	hits := 0
+	for i := 0; i < r; i++ {
+		if argmax(output.RawRowView(i)) == argmax(y.RawRowView(i)) {
+			hits++
+		}
+	}
+	return float64(hits) / float64(r)
+}
+
+//This function gets the index of the highest value of a slice
+func argmax(values []float64) int {
+	index := 0
+	for i, v := range values {
+		if v > values[index] {
+			index = i
+		}
+	}
+	return index
+}
+
+//This function copies every matrix of a slice
+func copyAll(matrixes []*mat.Dense) []*mat.Dense {
+	var copies []*mat.Dense
+	for _, m := range matrixes {
+		copies = append(copies, mat.DenseCopyOf(m))
+	}
+	return copies
+}
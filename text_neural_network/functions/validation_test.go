@@ -0,0 +1,142 @@
+package functions
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+//This function gets the expected output matrix of rows of the given categories
+func oneHot(rows []int, categories int) *mat.Dense {
+	y := mat.NewDense(len(rows), categories, nil)
+	for i, c := range rows {
+		y.Set(i, c, 1)
+	}
+	return y
+}
+
+func TestStratifiedSplit(t *testing.T) {
+	//Five rows of category 0, one of category 1 and three of category 2
+	y := oneHot([]int{0, 0, 1, 0, 2, 0, 2, 0, 2}, 3)
+	tests := []struct {
+		fraction float64
+		//Rows held out of every category
+		held []int
+	}{
+		{0, []int{0, 0, 0}},
+		{0.2, []int{1, 0, 1}},
+		{0.5, []int{3, 0, 2}},
+		//Every category keeps at least one row for training
+		{0.9, []int{4, 0, 2}},
+	}
+	for _, test := range tests {
+		train, validation := stratifiedSplit(y, test.fraction, nil, rand.New(rand.NewSource(1)))
+		held := make([]int, 3)
+		for _, i := range validation {
+			held[argmax(y.RawRowView(i))]++
+		}
+		for c := range held {
+			if held[c] != test.held[c] {
+				t.Errorf("fraction %v: held out %v rows of every category, want %v", test.fraction, held, test.held)
+				break
+			}
+		}
+		//Every row is on one side only
+		all := append(append([]int(nil), train...), validation...)
+		sort.Ints(all)
+		for i, row := range all {
+			if row != i {
+				t.Errorf("fraction %v: the rows of both sides are %v", test.fraction, all)
+				break
+			}
+		}
+	}
+}
+
+func TestSplitDb(t *testing.T) {
+	db := map[string][]string{"a": {"1", "2", "3", "4"}, "b": {"5"}, "c": {"6", "7"}}
+	tests := []struct {
+		fraction float64
+		held     map[string]int
+	}{
+		{0, map[string]int{}},
+		{0.25, map[string]int{"a": 1, "c": 1}},
+		{0.5, map[string]int{"a": 2, "c": 1}},
+		{0.99, map[string]int{"a": 3, "c": 1}},
+	}
+	for _, test := range tests {
+		train, held := SplitDb(db, test.fraction, rand.New(rand.NewSource(1)))
+		for category, sentences := range db {
+			if len(held[category]) != test.held[category] {
+				t.Errorf("fraction %v: held out %d sentences of %s, want %d", test.fraction, len(held[category]), category, test.held[category])
+			}
+			if len(train[category])+len(held[category]) != len(sentences) {
+				t.Errorf("fraction %v: %s has %d+%d sentences, want %d", test.fraction, category, len(train[category]), len(held[category]), len(sentences))
+			}
+		}
+	}
+}
+
+func TestLossAccuracy(t *testing.T) {
+	y := oneHot([]int{0, 1}, 2)
+	tests := []struct {
+		output     []float64
+		activation string
+		loss       float64
+		accuracy   float64
+	}{
+		{[]float64{1, 0, 0, 1}, SIGMOID, 0, 1},
+		{[]float64{0.5, 0.5, 0.5, 0.5}, SIGMOID, 0.5, 0.5},
+		{[]float64{0, 1, 1, 0}, SIGMOID, 2, 0},
+		//Cross-entropy is the mean of minus the log of the probability of the right category
+		{[]float64{0.75, 0.25, 0.5, 0.5}, SOFTMAX, -(math.Log(0.75) + math.Log(0.5)) / 2, 0.5},
+		//A zero probability is clipped so the loss is not infinite
+		{[]float64{0, 1, 0, 1}, SOFTMAX, -math.Log(1e-15) / 2, 0.5},
+	}
+	for _, test := range tests {
+		output := mat.NewDense(2, 2, test.output)
+		if got := loss(output, y, test.activation); math.Abs(got-test.loss) > 1e-12 {
+			t.Errorf("loss(%v, %s) = %v, want %v", test.output, test.activation, got, test.loss)
+		}
+		if got := accuracy(output, y); got != test.accuracy {
+			t.Errorf("accuracy(%v) = %v, want %v", test.output, got, test.accuracy)
+		}
+	}
+}
+
+func TestTrainValidation(t *testing.T) {
+	_, x, y, words, categories := toyData()
+	tests := []struct {
+		validation float64
+		patience   int
+		err        bool
+	}{
+		{0.25, 0, false},
+		{0.25, 5, false},
+		{1, 0, true},
+		{-0.5, 0, true},
+	}
+	for _, test := range tests {
+		config := toyConfig()
+		config.Validation, config.Patience = test.validation, test.patience
+		model, err := Train(x, y, config, words, categories)
+		if (err != nil) != test.err {
+			t.Errorf("validation %v: error = %v, want error %t", test.validation, err, test.err)
+			continue
+		}
+		if test.err {
+			continue
+		}
+		metrics := model.Metrics
+		if metrics.BestEpoch > metrics.Epochs || metrics.ValidationAccuracy <= 0 || metrics.ValidationLoss <= 0 {
+			t.Errorf("validation %v, patience %d: metrics %+v", test.validation, test.patience, metrics)
+		}
+		//With patience the training stops once the validation doesn't get better for that many epochs
+		if test.patience > 0 && metrics.Epochs-metrics.BestEpoch > test.patience {
+			t.Errorf("patience %d: best epoch %d but trained until %d", test.patience, metrics.BestEpoch, metrics.Epochs)
+		}
+	}
+}
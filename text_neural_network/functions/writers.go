@@ -0,0 +1,210 @@
+package functions
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//DatasetWriter writes a dataset in one format, the one its reader reads
+type DatasetWriter interface {
+	Write(w io.Writer, dataset *Dataset) error
+}
+
+//The writers of every extension, more can be added with RegisterWriter
+var writers = map[string]DatasetWriter{
+	".txt":   phrasesWriter{},
+	".csv":   csvWriter{},
+	".jsonl": jsonlWriter{},
+	".json":  jsonWriter{},
+	".yaml":  yamlWriter{},
+	".yml":   yamlWriter{},
+}
+
+//This function sets the writer of the dataset files with an extension, like ".tsv"
+func RegisterWriter(ext string, writer DatasetWriter) {
+	writers[strings.ToLower(ext)] = writer
+}
+
+//This function gets the writer of a dataset file from its extension
+func WriterFor(file string) (DatasetWriter, error) {
+	ext := strings.ToLower(filepath.Ext(file))
+	writer, ok := writers[ext]
+	if !ok {
+		var known []string
+		for k := range writers {
+			known = append(known, k)
+		}
+		sort.Strings(known)
+		return nil, fmt.Errorf("unknown dataset format %q of %s, it must be one of %s", ext, file, strings.Join(known, " "))
+	}
+	return writer, nil
+}
+
+//This function saves a dataset on a file with the writer of its extension.
+//Only the yaml format keeps the responses, save them apart with SaveIntents
+func SaveDataset(file string, dataset *Dataset) error {
+	writer, err := WriterFor(file)
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	if err := writer.Write(f, dataset); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+//This function saves the responses of every category with the layout of intents.json
+func SaveIntents(file string, responses map[string][]string) error {
+	content, err := json.MarshalIndent(Outmost{Category: responses}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, content, 0644)
+}
+
+//This function writes an example as a line of chatss.txt, escaping the backslashes and "#"
+//so the sentence is read back the same
+func FormatPhrase(example Example) (string, error) {
+	if strings.ContainsAny(example.Category, "()#\n") {
+		return "", fmt.Errorf("category %q can't have parenthesis, \"#\" or new lines", example.Category)
+	}
+	//A sentence is always on a single line, with its "#" escaped so it is not read as another example
+	text := strings.Join(strings.Fields(example.Text), " ")
+	text = strings.NewReplacer(`\`, `\\`, "#", `\#`).Replace(text)
+	line := fmt.Sprintf("#%s (%s)", text, example.Category)
+	if text == "" {
+		line = fmt.Sprintf("# (%s)", example.Category)
+	}
+	if source := strings.Join(strings.Fields(example.Source), " "); example.Generated && source != "" {
+		line += fmt.Sprintf(" %s: %s]", AUGMENTED_TAG[:len(AUGMENTED_TAG)-1], source)
+	} else if example.Generated {
+		line += " " + AUGMENTED_TAG
+	}
+	return line, nil
+}
+
+//phrasesWriter writes the "#sentence (category)" lines of chatss.txt
+type phrasesWriter struct{}
+
+func (phrasesWriter) Write(w io.Writer, dataset *Dataset) error {
+	out := bufio.NewWriter(w)
+	for _, example := range dataset.Examples {
+		line, err := FormatPhrase(example)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, line)
+	}
+	return out.Flush()
+}
+
+//csvWriter writes the text,intent header and a row for every example,
+//with a third augmented column and a fourth source one when there are generated examples
+type csvWriter struct{}
+
+func (csvWriter) Write(w io.Writer, dataset *Dataset) error {
+	_, generated := SplitGenerated(dataset.Examples)
+	out := csv.NewWriter(w)
+	if len(generated) > 0 {
+		out.Write([]string{"text", "intent", "augmented", "source"})
+	} else {
+		out.Write([]string{"text", "intent"})
+	}
+	for _, example := range dataset.Examples {
+		if len(generated) > 0 {
+			out.Write([]string{example.Text, example.Category, strconv.FormatBool(example.Generated), example.Source})
+		} else {
+			out.Write([]string{example.Text, example.Category})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+//jsonlWriter writes a {"text": ..., "intent": ...} object on every line
+type jsonlWriter struct{}
+
+func (jsonlWriter) Write(w io.Writer, dataset *Dataset) error {
+	encoder := json.NewEncoder(w)
+	for _, example := range dataset.Examples {
+		if err := encoder.Encode(newRecord(example)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//jsonWriter writes an array of {"text": ..., "intent": ...} objects
+type jsonWriter struct{}
+
+func (jsonWriter) Write(w io.Writer, dataset *Dataset) error {
+	records := []record{}
+	for _, example := range dataset.Examples {
+		records = append(records, newRecord(example))
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+//yamlWriter writes the examples and responses of every intent together
+type yamlWriter struct{}
+
+//yamlIntent is an intent of a yaml dataset, as it is written
+type yamlIntent struct {
+	Examples []string `yaml:"examples,omitempty"`
+	//The sentence of the variants made from a known sentence, and a text and source for the rest
+	Augmented []interface{} `yaml:"augmented,omitempty"`
+	Responses []string      `yaml:"responses,omitempty"`
+}
+
+//yamlVariant is a generated example of a yaml dataset with the sentence it was made from
+type yamlVariant struct {
+	Text   string `yaml:"text"`
+	Source string `yaml:"source"`
+}
+
+func (yamlWriter) Write(w io.Writer, dataset *Dataset) error {
+	intents := make(map[string]*yamlIntent)
+	get := func(name string) *yamlIntent {
+		if intents[name] == nil {
+			intents[name] = &yamlIntent{}
+		}
+		return intents[name]
+	}
+	for _, example := range dataset.Examples {
+		intent := get(example.Category)
+		if example.Generated && example.Source != "" {
+			intent.Augmented = append(intent.Augmented, yamlVariant{Text: example.Text, Source: example.Source})
+		} else if example.Generated {
+			intent.Augmented = append(intent.Augmented, example.Text)
+		} else {
+			intent.Examples = append(intent.Examples, example.Text)
+		}
+	}
+	for name, responses := range dataset.Responses {
+		get(name).Responses = responses
+	}
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(map[string]interface{}{"intents": intents}); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
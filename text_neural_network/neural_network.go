@@ -1,9 +1,15 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"os"
+	"os/signal"
+	"runtime"
 	"time"
 
 	"gonum.org/v1/gonum/mat"
@@ -12,50 +18,450 @@ import (
 )
 
 var training_data map[string][]string
+var generated_data []functions.Example
 var training *mat.Dense
 var words []string
 var categories []string
 var output *mat.Dense
-var hidden_neurons = 20
+var hidden_layers = "20:sigmoid"
 var alpha = 0.1
 var epochs = 100000
 var dropout = false
 var dropout_percent = 0.2
+var batch_size = 16
+var softmax = false
+var optimizer = "sgd"
+var schedule = "constant"
+var decay = 0.5
+var decay_step = 1000
+var validation = 0.2
+var patience = 500
 var details = false
+var max_distance = 2
+var threshold = functions.ERROR_THRESHOLD
+var stem = false
+var normalize = "lowercase,stopwords,fold_accents"
+var features = functions.BINARY
+var preprocessing = functions.DefaultPreprocessing()
+var seed int64 = 0
+
+//Default -out file of every command that saves one
+var out_files = map[string]string{
+	"convert": "model.gob",
+	"import":  "chatss.txt",
+	"export":  "nlu.yml",
+	"augment": "augmented.txt",
+}
 
 func main() {
-	//Separate file in lines
-	line, err := functions.ScanPhrases("./chatss.txt")
-	if err != nil {
-		panic(err)
-	}
-	//Get the training data, words database and categroies database from our lines database
-	training_data, words, categories = functions.SetDb(line)
-	//Get the corresponding binary matrix of every sentences database, and categories database
-	training, output = functions.Binarize(training_data, words, categories)
+	//Set flags for the training sentences, and the responses of every category
+	data_file := flag.String("data", "./chatss.txt", "Training sentences, either .txt like chatss.txt, .csv (text,intent), .jsonl, .json or .yaml")
+	intents_file := flag.String("intents", "intents.json", "Responses of every category, either intents.json or a .yaml dataset with responses")
 	//Set flag to be able to decide from cmd, train or test
-	command := flag.String("command", "test", "Either train or test to evaluate neural network")
+	command := flag.String("command", "test", "Either train, test, eval, cv, tune, convert, import, export, lint or augment to evaluate neural network")
+	//Set flag for the model file, binary if its extension is .gob or .bin
+	model_file := flag.String("model", "model.json", "Model file to save or load, binary if it ends in .gob or .bin and json otherwise")
+	//Set flags for the files converted by the convert command
+	in := flag.String("in", "model.json", "Model file to convert, or dataset of another tool to import")
+	out := flag.String("out", "", "File to save the converted model, the imported dataset, the exported one or the augmented one on. By default model.gob, chatss.txt, nlu.yml and augmented.txt")
+	//Set flags for the datasets of other tools
+	format := flag.String("format", "", "Format to import or export: rasa, rasa_md or dialogflow. Empty to take it from the extension of the file")
+	lang := flag.String("lang", "es", "Language of the exported Dialogflow agent")
+	intents_out := flag.String("intents_out", "", "Also save the imported responses on this file, with the layout of intents.json")
+	//Set flags for the limits of the linter
+	lint_options := functions.DefaultLintOptions()
+	flag.IntVar(&lint_options.MinExamples, "min_examples", lint_options.MinExamples, "Categories with fewer examples are warned about by lint")
+	flag.IntVar(&lint_options.NearDistance, "near_distance", lint_options.NearDistance, "Sentences this many letters apart are near duplicates for lint")
+	strict := flag.Bool("strict", false, "Make lint fail on warnings too, not only on errors")
+	//Set flags for the variants made by augment
+	variants := flag.Int("variants", functions.DefaultAugmentOptions().Variants, "Variants augment makes of every sentence")
+	synonyms_file := flag.String("synonyms", "synonyms.txt", "File of synonyms for augment, every line a group of words that mean the same separated by commas, empty to not use synonyms")
 	//Set flag to be able to set a test sentence from cmd
 	user_input := flag.String("user_input", "lloro", "Type a sentence for the chat bot")
+	//Set flags for the labeled sentences to evaluate, and where to save the results
+	test_file := flag.String("test_file", "./chatss_test.txt", "File of labeled sentences to evaluate the model with, in any of the formats of -data")
+	json_out := flag.String("json_out", "", "Also save the evaluation report as json on this file")
+	//Set flag for the number of folds of the cross validation
+	folds := flag.Int("folds", 5, "Number of folds for the cross validation")
+	//Set flags for the hyperparameter search
+	space_file := flag.String("space", "./search_space.json", "Json file with the values to try for every hyperparameter")
+	search := flag.String("search", "grid", "Either grid, to try every combination of the space, or random")
+	trials := flag.Int("trials", 20, "Number of candidates of the random search")
+	workers := flag.Int("workers", runtime.NumCPU(), "Number of candidates trained at the same time")
+	leaderboard_file := flag.String("leaderboard", "leaderboard.json", "File to save the leaderboard of the search")
+	//Set flag to train with a softmax output, so the confidence is a probability
+	flag.BoolVar(&softmax, "softmax", softmax, "Train a softmax output layer with cross-entropy loss")
+	//Set flag to define the hidden layers, like "40:relu,20:tanh"
+	flag.StringVar(&hidden_layers, "layers", hidden_layers, "Hidden layers as size:activation (sigmoid, tanh or relu), separated by commas")
+	//Set flags to drop random hidden neurons while training
+	flag.BoolVar(&dropout, "dropout", dropout, "Use dropout on the hidden layers while training")
+	flag.Float64Var(&dropout_percent, "dropout_percent", dropout_percent, "Probability of dropping each hidden neuron")
+	//Set flag for the examples of every gradient step
+	flag.IntVar(&batch_size, "batch_size", batch_size, "Examples of every mini-batch, shuffled on every epoch, 0 to use all of them at once")
+	//Set flags to hold out examples for validation, and stop when they don't get better
+	flag.Float64Var(&validation, "validation", validation, "Fraction of the examples of every category held out for validation, 0 to train with all")
+	flag.IntVar(&patience, "patience", patience, "Epochs without improving the validation accuracy before stopping, 0 to never stop early")
+	//Set flags to choose how the weights are updated, and how the learning rate changes
+	flag.Float64Var(&alpha, "alpha", alpha, "Initial learning rate")
+	flag.StringVar(&optimizer, "optimizer", optimizer, "Either sgd, momentum, rmsprop or adam")
+	flag.StringVar(&schedule, "schedule", schedule, "Learning rate schedule, either constant, step, exponential or cosine")
+	flag.Float64Var(&decay, "decay", decay, "Factor the step and exponential schedules multiply the learning rate by every decay_step epochs")
+	flag.IntVar(&decay_step, "decay_step", decay_step, "Epochs between learning rate decays")
+	//Set flag to read the words that are not on the vocabulary as the closest one that is
+	flag.IntVar(&max_distance, "max_distance", max_distance, "Edits a word can be from a word of the vocabulary to be read as it, saved with the model, 0 to only read exact words")
+	//Set flag for the score a category needs, below it the sentence is answered with noanswer
+	flag.Float64Var(&threshold, "threshold", threshold, "Score the best category needs for a sentence to be understood, saved with the model. With -softmax and many categories a lower one may be better")
+	flag.BoolVar(&details, "details", details, "Print how the sentence is read, with the typos corrected")
+	//Set flags to read the words as their stem, or their lemma, so "pizzas" is the same word as "pizza"
+	flag.BoolVar(&stem, "stem", stem, "Cut every word to its spanish stem when training, saved with the model")
+	lemmas_file := flag.String("lemmas", "", "File of lemmas, every line a lemma and the words read as it, to read them as it when training. Empty to not use lemmas")
+	//Set flags for the steps that turn a sentence into words, saved with the model
+	flag.StringVar(&normalize, "normalize", normalize, "Steps that turn a sentence into words when training, in order and separated by commas: nfkc, lowercase, punctuation, numbers, emoji, stopwords, lemmas, stem or fold_accents")
+	//Set flag for the features the network takes from the words of a sentence
+	flag.StringVar(&features, "features", features, "Features of the words of a sentence, saved with the model: binary (bag of words), tf (term frequency) or tfidf (term frequency times inverse document frequency)")
+	stopwords_file := flag.String("stopwords", "", "File of the stopwords of the language, like stopwords_es.txt, saved with the model. Empty for the default spanish ones")
+
+	checkpoint := flag.String("checkpoint", "checkpoint.json", "File to save the training checkpoints on, empty to not save them")
+	checkpoint_every := flag.Int("checkpoint_every", 500, "Epochs between training checkpoints")
+	resume := flag.String("resume", "", "Checkpoint file to continue the training from")
+
+	flag.Int64Var(&seed, "seed", seed, "Seed of the random numbers, the same seed trains the same model and gives the same answers. 0 takes one from the clock")
 	flag.Parse()
+	if seed == 0 {
+		seed = time.Now().UTC().UnixNano()
+	}
+	steps, err := functions.ParseSteps(normalize)
+	if err != nil {
+		panic(err)
+	}
+	var lemmas map[string]string
+	if *lemmas_file != "" {
+		if lemmas, err = functions.LoadLemmas(*lemmas_file); err != nil {
+			panic(err)
+		}
+	}
+	var stopword_list []string
+	if *stopwords_file != "" {
+		if stopword_list, err = functions.LoadStopwords(*stopwords_file); err != nil {
+			panic(err)
+		}
+	}
+	preprocessing = functions.NewPreprocessing(steps, stem, lemmas, stopword_list)
+	lint_options.Preprocessing = preprocessing
+	//Every command saves a different kind of file, a model or a dataset
+	if *out == "" {
+		*out = out_files[*command]
+	}
 
 	// train the network or test to determine the effectiveness of the trained network
 	switch *command {
 	case "train":
+		sources := loadTraining(*data_file)
+		config := trainConfig()
+		config.Checkpoint, config.CheckpointEvery = *checkpoint, *checkpoint_every
+		config.Sources = sources
+		//The variants not made from a sentence of the dataset can't be kept apart from it, they are left out
+		left_out := len(generated_data)
+		for _, source := range sources {
+			if source >= 0 {
+				left_out--
+			}
+		}
+		if left_out > 0 {
+			fmt.Printf("%d variants are not trained with, the sentence they were made from is not on %s\n", left_out, *data_file)
+		}
+		manifest := functions.Manifest{Dataset: *data_file}
+		if *resume != "" {
+			cp, err := functions.LoadCheckpoint(*resume)
+			if err != nil {
+				panic(err)
+			}
+			//Use the same seed, so the validation examples are the same ones
+			config.Resume, config.Seed = cp, cp.Seed
+			manifest.ResumedFrom = cp.Epoch
+		}
+		fmt.Printf("Seed: %d\n", config.Seed)
+		//On Ctrl+C stop the training after the current epoch, saving a checkpoint
+		stop := make(chan struct{})
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		go func() {
+			<-interrupt
+			signal.Stop(interrupt)
+			close(stop)
+		}()
+		config.Stop = stop
 		//Start time
-		rand.Seed(time.Now().UTC().UnixNano())
 		t1 := time.Now()
 		//Train the database
-		functions.Train(training, output, hidden_neurons, alpha, epochs, dropout, dropout_percent, words, categories)
+		model, err := functions.Train(training, output, config, words, categories)
+		if errors.Is(err, functions.ErrInterrupted) {
+			if *checkpoint != "" {
+				fmt.Printf("Checkpoint saved on %s, continue with -resume=%s\n", *checkpoint, *checkpoint)
+			}
+			os.Exit(130)
+		}
+		if err != nil {
+			panic(err)
+		}
+		//Save the model into model.json, or the -model file
+		manifest.Seed, manifest.Config = config.Seed, config
+		saveModel(*model_file, model, manifest)
 		//End time
 		elapsed := time.Since(t1)
 		fmt.Printf("\nTime taken to train: %s\n", elapsed)
 	case "test":
-		//Load synapses, word database and categories database
-		synapse_0, synapse_1, words, categories := functions.LoadFile("model.json")
+		//Load synapses, word database, categories database and intents
+		bot, err := functions.NewBot(*model_file, *intents_file, seed)
+		if err != nil {
+			panic(err)
+		}
 		//Classify user input from cmd
-		functions.Classify(*user_input, details, synapse_0, synapse_1, words, categories)
+		if _, err := bot.Classify(*user_input, details); err != nil {
+			panic(err)
+		}
+	case "eval":
+		//Get the test database from the labeled file, the generated variants don't count
+		test_examples, _ := functions.SplitGenerated(loadExamples(*test_file))
+		test_data, _, _ := functions.SetDb(test_examples, preprocessing)
+		//Classify every sentence and compare with its category
+		model, err := functions.LoadFile(*model_file)
+		if err != nil {
+			panic(err)
+		}
+		report := functions.Evaluate(model, test_data)
+		report.Print(os.Stdout)
+		if *json_out != "" {
+			saveJSON(*json_out, report)
+		}
+	case "cv":
+		loadTraining(*data_file)
+		//Train and evaluate a model for every fold of the database
+		cv, err := functions.CrossValidate(training_data, generated_data, trainConfig(), *folds)
+		if err != nil {
+			panic(err)
+		}
+		cv.Print(os.Stdout)
+		if *json_out != "" {
+			saveJSON(*json_out, cv)
+		}
+	case "tune":
+		space, err := functions.LoadSearchSpace(*space_file)
+		if err != nil {
+			panic(err)
+		}
+		loadTraining(*data_file)
+		//Score the candidates on a held out split, the same validation fraction used to train
+		holdout := validation
+		if holdout <= 0 {
+			holdout = 0.2
+		}
+		leaderboard, model, err := functions.Tune(training_data, generated_data, trainConfig(), space, *search, *trials, *workers, holdout)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println()
+		functions.PrintLeaderboard(os.Stdout, leaderboard)
+		saveJSON(*leaderboard_file, leaderboard)
+		//Save the best model into model.json, or the -model file, with the hyperparameters it was trained with
+		manifest := functions.Manifest{Dataset: *data_file, Seed: model.Config.Seed, Config: *model.Config, Holdout: holdout}
+		saveModel(*model_file, model, manifest)
+	case "convert":
+		//Load the model in one format and save it in the other one
+		model, err := functions.LoadFile(*in)
+		if err != nil {
+			panic(err)
+		}
+		if err := functions.SaveFile(*out, model); err != nil {
+			panic(err)
+		}
+		fmt.Printf("Converted %s into %s\n", *in, *out)
+	case "import":
+		//Read the dataset of the other tool and save it in our format, from the extension of -out
+		if *format == "" {
+			detected, err := functions.NLUFormat(*in)
+			if err != nil {
+				panic(err)
+			}
+			*format = detected
+		}
+		dataset, err := functions.Import(*in, *format)
+		if err != nil {
+			panic(err)
+		}
+		if err := functions.SaveDataset(*out, dataset); err != nil {
+			panic(err)
+		}
+		if *intents_out != "" {
+			if err := functions.SaveIntents(*intents_out, dataset.Responses); err != nil {
+				panic(err)
+			}
+		}
+		fmt.Printf("Imported %d sentences and the responses of %d categories from %s into %s\n", len(dataset.Examples), len(dataset.Responses), *in, *out)
+	case "export":
+		//Save the training sentences and the responses in the format of the other tool
+		dataset, err := functions.LoadDataset(*data_file)
+		if err != nil {
+			panic(err)
+		}
+		if len(dataset.Responses) == 0 {
+			if dataset.Responses, err = functions.LoadIntens(*intents_file); err != nil {
+				panic(err)
+			}
+		}
+		if *format == "" {
+			detected, err := functions.NLUFormat(*out)
+			if err != nil {
+				panic(err)
+			}
+			*format = detected
+		}
+		empty, err := functions.Export(*out, *format, *lang, dataset)
+		if err != nil {
+			panic(err)
+		}
+		//The variants made by augment are not exported, and neither are the examples without a sentence
+		exported, _ := functions.SplitGenerated(dataset.Examples)
+		fmt.Printf("Exported %d sentences and the responses of %d categories into %s\n", len(exported)-empty, len(dataset.Responses), *out)
+		if empty > 0 {
+			fmt.Printf("Left out %d examples without a sentence, like noanswer, %s has no examples without one\n", empty, *format)
+		}
+	case "lint":
+		//Check the sentences, and the responses of their categories
+		dataset, err := functions.LoadDataset(*data_file)
+		if err != nil {
+			panic(err)
+		}
+		responses, responses_file := dataset.Responses, *data_file
+		if len(responses) == 0 {
+			if responses, err = functions.LoadIntens(*intents_file); err != nil {
+				panic(err)
+			}
+			responses_file = *intents_file
+		}
+		issues := functions.Lint(*data_file, dataset, responses_file, responses, lint_options)
+		errors_found, warnings := 0, 0
+		for _, issue := range issues {
+			fmt.Println(issue)
+			if issue.Severity == functions.LINT_ERROR {
+				errors_found++
+			} else {
+				warnings++
+			}
+		}
+		fmt.Printf("%d errors, %d warnings\n", errors_found, warnings)
+		//Fail so a broken dataset is caught before training with it
+		if errors_found > 0 || (*strict && warnings > 0) {
+			os.Exit(1)
+		}
+	case "augment":
+		//Make variants of every sentence to train with, with typos, synonyms and the words in another order
+		options := functions.DefaultAugmentOptions()
+		options.Variants = *variants
+		if *synonyms_file != "" {
+			synonyms, err := functions.LoadSynonyms(*synonyms_file)
+			if err != nil {
+				panic(err)
+			}
+			options.Synonyms = synonyms
+			options.Kinds = append(options.Kinds, functions.AUGMENT_SYNONYM)
+		}
+		dataset, err := functions.LoadDataset(*data_file)
+		if err != nil {
+			panic(err)
+		}
+		dataset.Examples = functions.Augment(dataset.Examples, options, rand.New(rand.NewSource(seed)))
+		if err := functions.SaveDataset(*out, dataset); err != nil {
+			panic(err)
+		}
+		_, made := functions.SplitGenerated(dataset.Examples)
+		fmt.Printf("Made %d variants of %d sentences into %s, train with -data=%s\n", len(made), len(dataset.Examples)-len(made), *out, *out)
 	default:
 		// don't do anything
 	}
 }
+
+//This function gets the training data, words database and categories database from our lines database,
+//with the variants made by augment apart, and the sentence every variant was made from.
+//Only the commands that train read the dataset, the others don't need it to exist
+func loadTraining(file string) []int {
+	examples := loadExamples(file)
+	originals, variants_found := functions.SplitGenerated(examples)
+	_, words, categories = functions.SetDb(examples, preprocessing)
+	training_data, _, _ = functions.SetDb(originals, preprocessing)
+	generated_data = variants_found
+	all_data, sources := functions.JoinGenerated(training_data, generated_data)
+	//Get the corresponding matrix of the words counts of every sentence, and categories database
+	training, output = functions.CountWords(all_data, words, categories, preprocessing)
+	return sources
+}
+
+//This function reads a dataset file in the format of its extension, and stops showing every example that can't be read
+func loadExamples(file string) []functions.Example {
+	dataset, err := functions.LoadDataset(file)
+	var parse_errs functions.ParseErrors
+	if errors.As(err, &parse_errs) {
+		for _, e := range parse_errs {
+			fmt.Fprintln(os.Stderr, e)
+		}
+		os.Exit(1)
+	}
+	if err != nil {
+		panic(err)
+	}
+	return dataset.Examples
+}
+
+//This function gets the training configuration from the global hyperparameters
+func trainConfig() functions.TrainConfig {
+	layers, err := functions.ParseLayers(hidden_layers)
+	if err != nil {
+		panic(err)
+	}
+	return functions.TrainConfig{
+		Layers:         layers,
+		Alpha:          alpha,
+		Epochs:         epochs,
+		Dropout:        dropout,
+		DropoutPercent: dropout_percent,
+		BatchSize:      batch_size,
+		Softmax:        softmax,
+		Validation:     validation,
+		Patience:       patience,
+		Optimizer:      optimizer,
+		Schedule:       functions.Schedule{Name: schedule, Decay: decay, Step: decay_step},
+		Seed:           seed,
+		MaxDistance:    max_distance,
+		Threshold:      threshold,
+		Preprocessing:  preprocessing,
+		Features:       features,
+	}
+}
+
+//This function saves a trained model with the sha256 of its dataset, and how it was trained
+//into model.manifest.json, to be able to train it again and check what it was trained with.
+//A model that couldn't be saved is a failed training, so it panics like the other commands
+func saveModel(file string, model *functions.Model, manifest functions.Manifest) {
+	var err error
+	if manifest.DatasetSHA256, err = functions.DatasetHash(manifest.Dataset); err != nil {
+		panic(err)
+	}
+	model.DatasetSHA256 = manifest.DatasetSHA256
+	if err := functions.SaveFile(file, model); err != nil {
+		panic(err)
+	}
+	if err := functions.SaveManifest(file, manifest); err != nil {
+		panic(err)
+	}
+}
+
+//This function saves a value as indented json on a file
+func saveJSON(file string, v interface{}) {
+	content, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	if err := ioutil.WriteFile(file, content, 0644); err != nil {
+		panic(err)
+	}
+}
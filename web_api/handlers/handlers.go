@@ -2,21 +2,63 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"log"
 	"net/http"
 	"text_neural_network/functions"
 )
 
 var detail bool
 
-//A handler to fetch all the jobs
-func GetResponse(w http.ResponseWriter, r *http.Request) {
-	//make a slice to hold our jobs data
-	synapse_0, synapse_1, words, categories := functions.LoadFile("C:\\Users\\jrtor\\go\\src\\text_neural_network\\model.json")
-	val := r.FormValue("msg")
-	category := functions.Classify(val, detail, synapse_0, synapse_1, words, categories)
+//A handler to answer the user message with the given bot, and the typos it corrected to read it
+func GetResponse(bot *functions.Bot) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		val := r.FormValue("msg")
+		category, err := bot.Classify(val, detail)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
 
-	w.Header().Set("Content-Type", "application/json")
-	for _, items := range category {
-		json.NewEncoder(w).Encode(items)
+		w.Header().Set("Content-Type", "application/json")
+		for _, items := range category {
+			json.NewEncoder(w).Encode(items)
+		}
+	}
+}
+
+//A handler for when the bot couldn't be built, it answers every message with the error
+func Unavailable(err error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, err)
+	}
+}
+
+//This function gets the http status of an error: the user sent something wrong, the bot
+//files are missing, or they are broken
+func Status(err error) int {
+	switch {
+	case errors.Is(err, functions.ErrEmptySentence):
+		return http.StatusBadRequest
+	case errors.Is(err, functions.ErrModelNotFound), errors.Is(err, functions.ErrIntentsNotFound):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
 	}
 }
+
+//Messages the clients get for every status, the error itself has paths of the server so it is only logged
+var messages = map[int]string{
+	http.StatusBadRequest:          "the message is empty",
+	http.StatusServiceUnavailable:  "the chatbot is not available",
+	http.StatusInternalServerError: "the chatbot could not answer",
+}
+
+//This function answers with the status of the error and its message as json, and logs the error
+func writeError(w http.ResponseWriter, err error) {
+	status := Status(err)
+	log.Printf("%d: %v", status, err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"Error": messages[status]})
+}
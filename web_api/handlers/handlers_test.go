@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text_neural_network/functions"
+)
+
+func TestStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{functions.ErrEmptySentence, http.StatusBadRequest},
+		{fmt.Errorf("%w: /srv/bot/model.json", functions.ErrModelNotFound), http.StatusServiceUnavailable},
+		{fmt.Errorf("%w: /srv/bot/intents.json", functions.ErrIntentsNotFound), http.StatusServiceUnavailable},
+		{fmt.Errorf("%w /srv/bot/model.json: bad checksum", functions.ErrCorruptModel), http.StatusInternalServerError},
+		{fmt.Errorf("%w /srv/bot/intents.json: no category", functions.ErrCorruptIntents), http.StatusInternalServerError},
+		{fmt.Errorf("%w for category greeting", functions.ErrNoResponse), http.StatusInternalServerError},
+	}
+	for _, test := range tests {
+		if got := Status(test.err); got != test.want {
+			t.Errorf("Status(%v) = %d, want %d", test.err, got, test.want)
+		}
+	}
+}
+
+func TestUnavailable(t *testing.T) {
+	tests := []struct {
+		err     error
+		status  int
+		message string
+	}{
+		{fmt.Errorf("%w: /srv/bot/model.json", functions.ErrModelNotFound), http.StatusServiceUnavailable, "the chatbot is not available"},
+		{fmt.Errorf("%w /srv/bot/intents.json: unexpected end of JSON input", functions.ErrCorruptIntents), http.StatusInternalServerError, "the chatbot could not answer"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		Unavailable(test.err)(w, httptest.NewRequest("POST", "/", nil))
+		if w.Code != test.status {
+			t.Errorf("%v: status %d, want %d", test.err, w.Code, test.status)
+		}
+		//The client never sees the paths of the server
+		if strings.Contains(w.Body.String(), "/srv/bot") {
+			t.Errorf("%v: the answer has the path of the server: %s", test.err, w.Body.String())
+		}
+		var body map[string]string
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["Error"] != test.message {
+			t.Errorf("%v: answer %s, want the message %q", test.err, w.Body.String(), test.message)
+		}
+	}
+}
+
+func TestGetResponse(t *testing.T) {
+	dir := t.TempDir()
+	//A model that reads hola as greeting
+	model := filepath.Join(dir, "model.json")
+	content := `{"Synapse_0": {"Rows": 1, "Cols": 2, "Stride": 2, "Data": [10, 0]},
+		"Synapse_1": {"Rows": 2, "Cols": 1, "Stride": 1, "Data": [20, -25]},
+		"Words": ["hola"], "Categories": ["greeting"]}`
+	if err := ioutil.WriteFile(model, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	intents := filepath.Join(dir, "intents.json")
+	if err := ioutil.WriteFile(intents, []byte(`{"category": {"greeting": ["Hola!"], "noanswer": ["No entiendo"]}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	//The bot is built once and every request is answered with it
+	bot, err := functions.NewBot(model, intents, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := GetResponse(bot)
+	tests := []struct {
+		msg    string
+		status int
+		//Field and value expected on the json answer
+		field, want string
+	}{
+		{"hola", http.StatusOK, "Key", "Hola!"},
+		{"adios", http.StatusOK, "Key", "No entiendo"},
+		{"", http.StatusBadRequest, "Error", "the message is empty"},
+	}
+	for _, test := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/", strings.NewReader(url.Values{"msg": {test.msg}}.Encode()))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		handler(w, r)
+		if w.Code != test.status {
+			t.Errorf("%q: status %d, want %d", test.msg, w.Code, test.status)
+		}
+		var body map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body[test.field] != test.want {
+			t.Errorf("%q: answer %s, want %s %q", test.msg, w.Body.String(), test.field, test.want)
+		}
+		if got := w.Header().Get("Content-Type"); got != "application/json" {
+			t.Errorf("%q: content type %q", test.msg, got)
+		}
+	}
+}
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"text_neural_network/functions"
+	"time"
 	"web_api/handlers"
 
 	"github.com/go-chi/chi"
@@ -11,17 +14,36 @@ import (
 )
 
 func main() {
-	fmt.Println("Starting server on port :3000")
+	//Set flags for the files the bot is built from, and where to serve it
+	model := flag.String("model", "../text_neural_network/model.json", "Path of the trained model file")
+	intents := flag.String("intents", "../text_neural_network/intents.json", "Path of the intents responses file")
+	addr := flag.String("addr", ":3000", "Address the server listens on")
+	seed := flag.Int64("seed", 0, "Seed to choose the responses with, 0 takes one from the clock")
+	flag.Parse()
+	if *seed == 0 {
+		*seed = time.Now().UTC().UnixNano()
+	}
+
+	//Load the model and intents only once, every request shares the same bot
+	bot, err := functions.NewBot(*model, *intents, *seed)
+	chatbot := handlers.GetResponse(bot)
+	if err != nil {
+		//Keep serving the page, the chatbot answers with the error until the server is restarted with good files
+		log.Printf("The chatbot can't answer: %v", err)
+		chatbot = handlers.Unavailable(err)
+	}
+
+	fmt.Printf("Starting server on port %s\n", *addr)
 	router := chi.NewRouter()
 	router.Use(middleware.Logger)
 
 	// Set up static file serving
 	fs := http.FileServer(http.Dir("./html"))
 	router.Handle("/*", fs)
-	router.Get("/chatbot", handlers.GetResponse)
+	router.Get("/chatbot", chatbot)
 
-	//run it on port 8080
-	err := http.ListenAndServe(":3000", router)
+	//run it on the given address
+	err = http.ListenAndServe(*addr, router)
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -13,5 +13,5 @@ type Entry struct {
 type Entries []Entry
 
 func (s Entries) Len() int           { return len(s) }
-func (s Entries) Less(i, j int) bool { return s[i].val < s[j].val }
+func (s Entries) Less(i, j int) bool { return s[i].Val < s[j].Val }
 func (s Entries) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }